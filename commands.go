@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+	"github.com/eazynow/goquote/quote"
+	"github.com/eazynow/goquote/rpc"
+	"github.com/eazynow/goquote/rpc/quotepb"
+	"github.com/eazynow/goquote/server"
+	"google.golang.org/grpc"
+)
+
+// stdinFilename is the filename used to read a market from standard input.
+const stdinFilename = "-"
+
+// marketFlags holds the flags shared by every command that imports a market
+// file.
+type marketFlags struct {
+	format    string
+	delimiter string
+	lenient   bool
+	// mode is how bad rows are handled when -lenient is not set.
+	mode lender.ImportMode
+}
+
+// register adds the market flags to fs.
+func (f *marketFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "", "the format of the market file: csv, json, jsonl or yaml (default detected from the file)")
+	fs.StringVar(&f.delimiter, "delimiter", "", `the character separating the fields of a csv market file, or "tab" (default a comma, or a tab for .tsv files)`)
+	fs.BoolVar(&f.lenient, "lenient", false, "skip rows of the market file that cannot be imported, listing them on stderr")
+}
+
+// options returns the import options selected by the market flags.
+// Returns the ImportOptions if the flags are valid, or a usageError otherwise.
+func (f *marketFlags) options() (lender.ImportOptions, error) {
+	opts := lender.ImportOptions{Mode: f.mode}
+	if f.lenient {
+		opts.Mode = lender.ImportLenient
+	}
+
+	if f.format != "" {
+		format, err := lender.ParseFormat(f.format)
+		if err != nil {
+			return opts, &usageError{err.Error()}
+		}
+		opts.Format = format
+	}
+
+	switch delimiter := []rune(f.delimiter); {
+	case f.delimiter == "":
+		// Use the default for the file.
+	case f.delimiter == "tab" || f.delimiter == `\t`:
+		opts.Delimiter = '\t'
+	case len(delimiter) == 1:
+		opts.Delimiter = delimiter[0]
+	default:
+		return opts, &usageError{fmt.Sprintf("The delimiter %q must be a single character", f.delimiter)}
+	}
+
+	return opts, nil
+}
+
+// load imports the market file located by filename using the market flags,
+// and checks the lenders against the rules. A filename of "-" reads the market
+// from standard input. Warnings, and rows or lenders skipped with -lenient,
+// are listed on stderr.
+// Returns the Lenders if successful, or a usageError or marketError
+// otherwise.
+func (f *marketFlags) load(filename string, rules lender.Rules) (lender.Lenders, error) {
+	opts, err := f.options()
+	if err != nil {
+		return nil, err
+	}
+
+	var lenders lender.Lenders
+	if filename == stdinFilename {
+		lenders, err = lender.Read(os.Stdin, opts)
+	} else {
+		lenders, err = lender.Import(filename, opts)
+	}
+
+	var skipped lender.ImportErrors
+	if f.lenient && errors.As(err, &skipped) && len(lenders) > 0 {
+		fmt.Fprintf(stderr, "Skipping rows of %s that cannot be imported. %s\n", filename, err)
+		err = nil
+	}
+	if err != nil {
+		return nil, &marketError{err}
+	}
+
+	findings := lenders.Validate(rules)
+	for _, warning := range findings.Warnings() {
+		fmt.Fprintf(stderr, "Warning for %s: %s\n", filename, warning)
+	}
+
+	if err := findings.Err(); err != nil {
+		lenders = lenders.Without(findings)
+		if !f.lenient || len(lenders) == 0 {
+			return nil, &marketError{err}
+		}
+		fmt.Fprintf(stderr, "Skipping lenders of %s that are not valid. %s\n", filename, err)
+	}
+	return lenders, nil
+}
+
+// requestFlags holds the flags shared by every command that builds a quote.
+type requestFlags struct {
+	marketFlags
+
+	term       int
+	policyFile string
+	output     string
+	rounding   string
+	currency   string
+	strategy   string
+	partial    bool
+}
+
+// register adds the shared quote flags to fs.
+func (f *requestFlags) register(fs *flag.FlagSet) {
+	f.marketFlags.register(fs)
+	fs.IntVar(&f.term, "term", quote.DefaultTerm, "the loan length in months")
+	fs.StringVar(&f.policyFile, "policy", "", "load the quote policy from a JSON or YAML file")
+	fs.StringVar(&f.currency, "currency", "", "the currency of the amount (default the currency of the market)")
+	fs.StringVar(&f.strategy, "strategy", quote.CheapestFirst{}.String(), "how the loan is shared between lenders: cheapest-first, pro-rata, diversified:<share>, spread:<count> or fewest-lenders")
+	fs.BoolVar(&f.partial, "partial", false, "quote for as much as the market can fund if it cannot fund the whole amount")
+	fs.StringVar(&f.rounding, "rounding", money.HalfUp.String(), "how pennies are rounded: half-up or half-even")
+	fs.StringVar(&f.output, "output", string(quote.FormatText), "the output format: text, json, yaml or csv")
+}
+
+// format returns the output format selected by the -output flag.
+// Returns the Format if it is supported, or a usageError otherwise.
+func (f *requestFlags) format() (quote.Format, error) {
+	format, err := quote.ParseFormat(f.output)
+	if err != nil {
+		return format, &usageError{err.Error()}
+	}
+	return format, nil
+}
+
+// request builds a quote request from the positional arguments [filename]
+// [amount] and the shared flags.
+// Returns the QuoteRequest if successful, or the associated error otherwise.
+func (f *requestFlags) request(args []string) (quote.QuoteRequest, error) {
+	var req quote.QuoteRequest
+
+	if len(args) != 2 {
+		return req, &usageError{"Expected the arguments [filename] [amount]"}
+	}
+
+	// Check that the amount provided is a valid integer.
+	amount, err := strconv.Atoi(args[1])
+	if err != nil {
+		return req, &usageError{fmt.Sprintf("The amount %s is not a valid integer", args[1])}
+	}
+
+	// The policy holds the rules the market is checked against.
+	policy, err := loadPolicy(f.policyFile)
+	if err != nil {
+		return req, err
+	}
+
+	// Attempt to import the csv file into a lender.Lenders slice
+	lenders, err := f.load(args[0], policy.Market)
+	if err != nil {
+		return req, err
+	}
+
+	if f.currency != "" {
+		if _, err := money.LookupCurrency(f.currency); err != nil {
+			return req, &usageError{err.Error()}
+		}
+	}
+
+	rounding, err := money.ParseRoundingMode(f.rounding)
+	if err != nil {
+		return req, &usageError{err.Error()}
+	}
+
+	strategy, err := quote.ParseStrategy(f.strategy)
+	if err != nil {
+		return req, &usageError{err.Error()}
+	}
+
+	req.Amount = amount
+	req.Currency = f.currency
+	req.Term = f.term
+	req.Lenders = lenders
+	req.Options.Policy = &policy
+	req.Options.Rounding = rounding
+	req.Options.Strategy = strategy
+	req.Options.AllowPartial = f.partial
+
+	return req, nil
+}
+
+// loadPolicy loads the policy from filename, or uses the default policy if
+// no filename is given.
+// Returns the Policy if successful, or a policyError otherwise.
+func loadPolicy(filename string) (quote.Policy, error) {
+	if filename == "" {
+		return quote.DefaultPolicy(), nil
+	}
+
+	policy, err := quote.LoadPolicy(filename)
+	if err != nil {
+		return policy, &policyError{err}
+	}
+	return policy, nil
+}
+
+// newFlagSet creates the flag set for a command, with a usage message that
+// shows how the command is called.
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	// parseFlags prints any errors and the usage itself, so that help that
+	// was asked for goes to stdout and everything else to stderr.
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goquote %s [flags] %s\n\nFlags:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the command line arguments for a command.
+// Returns flag.ErrHelp if help was requested, a usageError if the arguments
+// are invalid, or nil otherwise.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == flag.ErrHelp {
+		fs.SetOutput(stdout)
+		fs.Usage()
+		return err
+	}
+	if err != nil {
+		// Report the problem in the same way as the flag package.
+		fs.SetOutput(stderr)
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return &usageError{}
+	}
+	return nil
+}
+
+// runQuote calculates and displays a quote.
+func runQuote(args []string) error {
+	var (
+		rf           requestFlags
+		compare      bool
+		showLenders  bool
+		showSchedule bool
+	)
+
+	fs := newFlagSet("quote", "[filename] [amount]")
+	rf.register(fs)
+	fs.BoolVar(&compare, "compare", false, "compare quotes across every allowed term")
+	fs.BoolVar(&showLenders, "allocations", false, "print how much is drawn from each lender")
+	fs.BoolVar(&showSchedule, "schedule", false, "print the monthly repayment schedule")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	format, err := rf.format()
+	if err != nil {
+		return err
+	}
+
+	req, err := rf.request(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// Display a comparison across all terms if requested.
+	if compare {
+		c, err := quote.Compare(req)
+		if err != nil {
+			return err
+		}
+
+		return c.Encode(stdout, format)
+	}
+
+	// Attempt to create a new quote based on the input parameters
+	q, err := quote.Calculate(req)
+	if err != nil {
+		return err
+	}
+
+	// Structured formats always include the allocations.
+	if format != quote.FormatText {
+		return q.Encode(stdout, format, showSchedule)
+	}
+
+	// Display the quote
+	fmt.Fprintln(stdout, q.String())
+
+	currency := money.CurrencyOrDefault(q.Currency)
+
+	// Display the lender allocations if requested.
+	if showLenders {
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, q.Allocations.Format(currency))
+	}
+
+	// Display the repayment schedule if requested, starting from today.
+	if showSchedule {
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, q.Schedule(q.StartDate).Format(currency))
+	}
+
+	return nil
+}
+
+// runSchedule calculates a quote and displays its repayment schedule.
+func runSchedule(args []string) error {
+	var (
+		rf    requestFlags
+		start string
+	)
+
+	fs := newFlagSet("schedule", "[filename] [amount]")
+	rf.register(fs)
+	fs.StringVar(&start, "start", "", "the date the loan is drawn down as YYYY-MM-DD (default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	format, err := rf.format()
+	if err != nil {
+		return err
+	}
+
+	req, err := rf.request(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if start != "" {
+		req.Options.StartDate, err = time.Parse("2006-01-02", start)
+		if err != nil {
+			return &usageError{fmt.Sprintf("The start date %s is not a valid date", start)}
+		}
+	}
+
+	q, err := quote.Calculate(req)
+	if err != nil {
+		return err
+	}
+
+	if format != quote.FormatText {
+		return q.Encode(stdout, format, true)
+	}
+
+	fmt.Fprintln(stdout, q.Schedule(q.StartDate).Format(money.CurrencyOrDefault(q.Currency)))
+	return nil
+}
+
+// runValidateMarket checks that a market file can be imported, and that its
+// lenders follow the market rules of the policy, and displays a summary of the
+// lenders in it. Every bad row or lender is reported, not just the first.
+func runValidateMarket(args []string) error {
+	var (
+		mf         = marketFlags{mode: lender.ImportCollect}
+		policyFile string
+	)
+
+	fs := newFlagSet("validate-market", "[filename]")
+	mf.register(fs)
+	fs.StringVar(&policyFile, "policy", "", "check the market against the market rules of a JSON or YAML policy file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return &usageError{"Expected the argument [filename]"}
+	}
+
+	policy, err := loadPolicy(policyFile)
+	if err != nil {
+		return err
+	}
+
+	lenders, err := mf.load(fs.Arg(0), policy.Market)
+	if err != nil {
+		return err
+	}
+
+	// A market without lenders cannot be quoted from, and would not be
+	// reloaded by serve either.
+	if len(lenders) == 0 {
+		return &marketError{lender.ErrEmptyMarket}
+	}
+
+	// Every lender in the market must lend in the same currency.
+	code, err := lenders.Currency()
+	if err != nil {
+		return &marketError{err}
+	}
+	currency := money.CurrencyOrDefault(code)
+
+	available := 0
+	for _, l := range lenders {
+		available += l.Available
+	}
+
+	fmt.Fprintf(stdout, "%s: %d lenders with %s available\n", fs.Arg(0), len(lenders), currency.FormatMajor(available))
+	return nil
+}
+
+// runServe starts the HTTP JSON API, and the gRPC service if an address is
+// given for it, quoting from the market file.
+func runServe(args []string) error {
+	var (
+		mf         marketFlags
+		addr       string
+		grpcAddr   string
+		policyFile string
+		reload     time.Duration
+	)
+
+	fs := newFlagSet("serve", "[filename]")
+	mf.register(fs)
+	fs.StringVar(&addr, "addr", ":8080", "the address to listen on")
+	fs.StringVar(&grpcAddr, "grpc-addr", "", "the address to serve the gRPC API on, if any")
+	fs.StringVar(&policyFile, "policy", "", "load the quote policy from a JSON or YAML file")
+	fs.DurationVar(&reload, "reload", 5*time.Second, "how often to check the market file for changes, or 0 to never reload it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return &usageError{"Expected the argument [filename]"}
+	}
+
+	policy, err := loadPolicy(policyFile)
+	if err != nil {
+		return err
+	}
+
+	filename := fs.Arg(0)
+	lenders, err := mf.load(filename, policy.Market)
+	if err != nil {
+		return err
+	}
+
+	httpServer := server.New(lenders, policy)
+	errs := make(chan error, 2)
+
+	// A market read from standard input cannot change.
+	if reload > 0 && filename != stdinFilename {
+		opts, err := mf.options()
+		if err != nil {
+			return err
+		}
+
+		watcher, err := lender.NewWatcher(filename, lenders, opts, policy.Market)
+		if err != nil {
+			return &marketError{err}
+		}
+
+		// Quotes already being calculated keep the market they started with,
+		// and a market that fails to reload is never swapped in.
+		go watcher.Watch(context.Background(), reload,
+			func(lenders lender.Lenders) {
+				httpServer.SetMarket(lenders)
+				fmt.Fprintf(stderr, "Reloaded %d lenders from %s\n", len(lenders), filename)
+			},
+			func(err error, reloaded bool) {
+				// Rows are only skipped when the rest of the market was
+				// swapped in. Otherwise the previous market is kept.
+				if reloaded {
+					fmt.Fprintf(stderr, "Skipping rows of %s that cannot be used. %s\n", filename, err)
+					return
+				}
+				fmt.Fprintf(stderr, "Unable to reload %s, keeping the previous market: %s\n", filename, err)
+			})
+	}
+
+	if grpcAddr != "" {
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return err
+		}
+
+		// Both APIs share the market of the HTTP server.
+		grpcServer := grpc.NewServer()
+		quotepb.RegisterQuoteServiceServer(grpcServer, rpc.New(httpServer.Market, policy))
+
+		fmt.Fprintf(stderr, "Serving gRPC quotes for %s on %s\n", filename, grpcAddr)
+		go func() { errs <- grpcServer.Serve(listener) }()
+	}
+
+	fmt.Fprintf(stderr, "Serving quotes for %s on %s\n", filename, addr)
+	go func() { errs <- http.ListenAndServe(addr, httpServer) }()
+
+	return <-errs
+}
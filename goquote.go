@@ -1,63 +1,205 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
 
 	"github.com/eazynow/goquote/lender"
 	"github.com/eazynow/goquote/quote"
 )
 
+// Exit codes returned by goquote. Each class of error has its own code so that
+// scripts can tell them apart.
 const (
-	// loanPeriodMonths is the default loan length in months.
-	loanPeriodMonths = 36
+	// exitOK is returned when the command completed successfully.
+	exitOK = 0
+	// exitError is returned for any error not covered by a more specific code.
+	exitError = 1
+	// exitUsage is returned when the command line arguments are invalid.
+	exitUsage = 2
+	// exitMarket is returned when the market file cannot be imported.
+	exitMarket = 3
+	// exitPolicy is returned when the policy file cannot be loaded.
+	exitPolicy = 4
+	// exitRejected is returned when the quote request breaks the policy.
+	exitRejected = 5
+	// exitInsufficientFunds is returned when the market cannot fund the loan.
+	exitInsufficientFunds = 6
 )
 
+// The writers used for output. Results and help that was asked for are
+// written to stdout, and errors, warnings and progress to stderr. They are
+// variables so that the tests can capture the output.
 var (
-	filename string
-	amount   int
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
 )
 
-func init() {
-	// Skip the first arg as that is the program name.
-	args := os.Args[1:]
+// command represents a goquote subcommand.
+type command struct {
+	// name is what the user types to run the command.
+	name string
+	// summary is a one line description shown in the main usage.
+	summary string
+	// run executes the command with the arguments that follow its name.
+	run func(args []string) error
+}
+
+// commands is the list of subcommands goquote supports. The first command is
+// used when no command name is given.
+var commands = []command{
+	{"quote", "calculate a quote for a loan", runQuote},
+	{"schedule", "print the repayment schedule for a loan", runSchedule},
+	{"validate-market", "check that a market file can be imported", runValidateMarket},
+	{"serve", "serve quotes over HTTP as a JSON API", runServe},
+}
+
+// usageError is used when the command line arguments are invalid.
+type usageError struct {
+	msg string
+}
+
+// The Error function is used to satisfy the error interface.
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// marketError is used when the market file cannot be imported.
+type marketError struct {
+	err error
+}
+
+// The Error function is used to satisfy the error interface.
+func (e *marketError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying import error.
+func (e *marketError) Unwrap() error {
+	return e.err
+}
+
+// policyError is used when the policy file cannot be loaded.
+type policyError struct {
+	err error
+}
+
+// The Error function is used to satisfy the error interface.
+func (e *policyError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying load error.
+func (e *policyError) Unwrap() error {
+	return e.err
+}
 
-	if len(args) != 2 {
-		fmt.Printf("Usage: goquote [filename] [amount]")
-		os.Exit(0)
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run is the entry point of the command line utility. It finds the command
+// named by the first argument and runs it with the remaining arguments. If the
+// first argument is not a command, the quote command is run with all of the
+// arguments so that `goquote market.csv 1000` keeps working.
+// Returns the exit code for the process.
+func run(args []string) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
 	}
 
-	filename = args[0]
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		return help(args[1:])
+	}
 
-	// Check that the amount provided is a valid integer.
-	var err error
-	amount, err = strconv.Atoi(args[1])
-	if err != nil {
-		fmt.Printf("The amount %s is not a valid integer", args[1])
-		os.Exit(0)
+	cmd := commands[0]
+	if c, ok := findCommand(args[0]); ok {
+		cmd = c
+		args = args[1:]
 	}
+
+	err := cmd.run(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil && err.Error() != "" {
+		fmt.Fprintln(stderr, err)
+	}
+
+	return exitCode(err)
 }
 
-func main() {
+// help prints the usage of goquote, or of a single command if one is named.
+// Returns the exit code for the process.
+func help(args []string) int {
+	if len(args) == 0 {
+		usage(stdout)
+		return exitOK
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "Unknown command %q\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	cmd.run([]string{"-help"})
+	return exitOK
+}
 
-	// Attempt to import the csv file into a lender.Lenders slice
-	lenders, err := lender.ImportCSV(filename)
-	if err != nil {
-		// There was a problem importing the csv - bail!
-		fmt.Println(err)
-		os.Exit(0)
+// findCommand looks up a command by name.
+// Returns the command and true if found, or false otherwise.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
 	}
+	return command{}, false
+}
+
+// usage writes the list of commands to w.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: goquote <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'goquote help <command>' for the flags of a command.")
+}
 
-	// Attempt to create a new quote based on the input parameters
-	q, err := quote.NewQuote(amount, loanPeriodMonths, lenders)
+// exitCode maps an error returned by a command to the process exit code.
+// Returns exitOK if err is nil.
+func exitCode(err error) int {
+	var (
+		usageErr  *usageError
+		marketErr *marketError
+		policyErr *policyError
+	)
 
-	if err != nil {
-		// There was a problem creating the quote - bail!
-		fmt.Println(err)
-		os.Exit(0)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &marketErr),
+		errors.Is(err, lender.ErrMixedCurrencies):
+		return exitMarket
+	case errors.As(err, &policyErr):
+		return exitPolicy
+	case errors.Is(err, quote.ErrInsufficientFunds):
+		return exitInsufficientFunds
+	case quote.IsRejection(err):
+		return exitRejected
 	}
 
-	// Display the quote
-	fmt.Println(q.String())
+	return exitError
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/quote"
+	"github.com/stretchr/testify/assert"
+)
+
+// runCaptured runs goquote with the arguments, capturing what it writes.
+// Returns the exit code and the output written to stdout and stderr.
+func runCaptured(args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	oldStdout, oldStderr := stdout, stderr
+	stdout, stderr = &out, &errOut
+	defer func() {
+		stdout, stderr = oldStdout, oldStderr
+	}()
+
+	code := run(args)
+	return code, out.String(), errOut.String()
+}
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"success", nil, exitOK},
+		{"usage", &usageError{"Expected the arguments [filename] [amount]"}, exitUsage},
+		{"market", &marketError{errors.New("no such file")}, exitMarket},
+		{"mixed currencies", lender.ErrMixedCurrencies, exitMarket},
+		{"policy", &policyError{errors.New("bad policy")}, exitPolicy},
+		{"amount too low", quote.NewAmountError(quote.ErrAmountTooLow, 900, 1000, "GBP"), exitRejected},
+		{"term", &quote.TermError{Term: 18}, exitRejected},
+		{"currency", &quote.CurrencyError{Requested: "EUR", Market: "GBP"}, exitRejected},
+		{"fees", &quote.FeeError{Upfront: 100000, Amount: 1000, Currency: "GBP"}, exitRejected},
+		{"wrapped rejection", fmt.Errorf("quote: %w", &quote.TermError{Term: 18}), exitRejected},
+		{"insufficient funds", &quote.InsufficientFundsError{Requested: 5000, Shortfall: 2670}, exitInsufficientFunds},
+		{"other", errors.New("disk full"), exitError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, exitCode(tc.err))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected int
+		stdout   string
+		stderr   string
+	}{
+		{"no arguments", nil, exitUsage, "", "Usage: goquote <command>"},
+		{"help", []string{"help"}, exitOK, "Usage: goquote <command>", ""},
+		{"help for a command", []string{"help", "quote"}, exitOK, "Usage: goquote quote [flags]", ""},
+		{"help flag", []string{"quote", "-help"}, exitOK, "Usage: goquote quote [flags]", ""},
+		{"help for an unknown command", []string{"help", "borrow"}, exitUsage, "", `Unknown command "borrow"`},
+		{"missing amount", []string{"quote", "market.csv"}, exitUsage, "", "Expected the arguments [filename] [amount]"},
+		{"amount not a number", []string{"quote", "market.csv", "lots"}, exitUsage, "", "The amount lots is not a valid integer"},
+		{"unknown flag", []string{"quote", "-lots", "market.csv", "1000"}, exitUsage, "", "flag provided but not defined: -lots"},
+		{"unknown output", []string{"quote", "-output", "xml", "market.csv", "1000"}, exitUsage, "", "xml"},
+		{"missing market", []string{"quote", "missing.csv", "1000"}, exitMarket, "", "missing.csv"},
+		{"missing policy", []string{"quote", "-policy", "missing.json", "market.csv", "1000"}, exitPolicy, "", "missing.json"},
+		{"amount too low", []string{"market.csv", "900"}, exitRejected, "", "Loan amount of £900 is too low"},
+		{"amount not a step", []string{"quote", "market.csv", "1050"}, exitRejected, "", "Loan amount must be a multiple of £100"},
+		{"term not allowed", []string{"quote", "-term", "18", "market.csv", "1000"}, exitRejected, "", "Loan term of 18 months is not available"},
+		{"insufficient funds", []string{"quote", "market.csv", "15000"}, exitInsufficientFunds, "", "It is not possible to provide a quote at this time."},
+		{"quote", []string{"quote", "market.csv", "1000"}, exitOK, "Requested amount: £1000", ""},
+		{"default command", []string{"market.csv", "1000"}, exitOK, "Requested amount: £1000", ""},
+		{"validate market", []string{"validate-market", "market.csv"}, exitOK, "market.csv: 7 lenders with £2330 available", ""},
+		{"validate empty market", []string{"validate-market", "test_empty_market.csv"}, exitMarket, "", "The market does not contain any lenders"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code, out, errOut := runCaptured(tc.args...)
+
+			assert.Equal(t, tc.expected, code)
+			if tc.stdout == "" {
+				assert.Empty(t, out, "Expected nothing on stdout")
+			} else {
+				assert.Contains(t, out, tc.stdout)
+			}
+			if tc.stderr == "" {
+				assert.Empty(t, errOut, "Expected nothing on stderr")
+			} else {
+				assert.Contains(t, errOut, tc.stderr)
+			}
+		})
+	}
+}
@@ -2,23 +2,47 @@ package lender
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
-)
+	"strings"
 
-const (
-	// csvFieldsPerRow is the number of expected fields on every row of the
-	// csv file.
-	csvFieldsPerRow = 3
-	// csvNameIndex is the index reference for the Name field
-	csvNameIndex = 0
-	// csvRateIndex is the index reference for the Rate field
-	csvRateIndex = 1
-	// csvAmountIndex is the index reference for the Amount field
-	csvAmountIndex = 2
+	"github.com/eazynow/goquote/money"
 )
 
+// ErrMissingColumn is the cause of a FieldParseError on the header when a
+// required column cannot be found.
+var ErrMissingColumn = errors.New("The market file is missing a required column")
+
+// ErrDuplicateColumn is the cause of a FieldParseError on the header when
+// more than one column holds the same field.
+var ErrDuplicateColumn = errors.New("The market file has more than one column for a field")
+
+// csvColumn describes a field that can be read from a column of a csv file.
+type csvColumn struct {
+	// field is the name of the field used in errors.
+	field string
+	// aliases are the headers the column can have, in lower case with words
+	// separated by single spaces.
+	aliases []string
+	// required is true if every market file must have the column.
+	required bool
+}
+
+// csvColumns are the fields that can be read from a csv file. Columns with
+// any other header are ignored.
+var csvColumns = []csvColumn{
+	{"name", []string{"lender", "name", "lender name", "lender id"}, true},
+	{"rate", []string{"rate", "interest rate", "lender rate"}, true},
+	{"available", []string{"available", "amount", "amount available", "available amount"}, true},
+	{"currency", []string{"currency", "ccy", "currency code"}, false},
+	{"min_term", []string{"min term", "minimum term"}, false},
+	{"max_term", []string{"max term", "maximum term"}, false},
+	{"risk_band", []string{"risk band", "band"}, false},
+}
+
 // FieldParseError is an error structure that is used when importing a csv
 // file. A custom structure is used to provide additional information
 // when debugging an import problem of a csv file.
@@ -27,19 +51,60 @@ type FieldParseError struct {
 	Cause error
 	// LineNo is the line (row) that the error occurred on.
 	LineNo int
+	// Record is the position of the lender in formats where a lender can span
+	// several lines, such as JSON and YAML. It is only set when LineNo is
+	// zero.
+	Record int
 	// Field is the name of the field where the error occurrd.
 	Field string
+	// Column is the name of the column in the file holding the field, if the
+	// file has named columns.
+	Column string
+	// Value is the raw value that could not be parsed, if there was one.
+	Value string
 }
 
 // The Error function is used to satisfy the error interface.
 // Returns a string represntation of the FieldParseError.
 func (l *FieldParseError) Error() string {
+	if l.LineNo == 0 {
+		return fmt.Sprintf("Error unmarshalling %s field of record %d. Cause: %s",
+			l.Field,
+			l.Record,
+			l.Cause.Error())
+	}
 	return fmt.Sprintf("Error unmarshalling %s field on line %d. Cause: %s",
 		l.Field,
 		l.LineNo,
 		l.Cause.Error())
 }
 
+// Unwrap returns the Cause so the error can be checked using errors.Is and
+// errors.As.
+func (l *FieldParseError) Unwrap() error {
+	return l.Cause
+}
+
+// summary is a private function that describes the error on a single line,
+// including the column and raw value, for listing in an ImportErrors.
+// Returns a string such as `line 4, Rate "bad_rate": invalid syntax`.
+func (l *FieldParseError) summary() string {
+	position := fmt.Sprintf("line %d", l.LineNo)
+	if l.LineNo == 0 {
+		position = fmt.Sprintf("record %d", l.Record)
+	}
+
+	column := l.Column
+	if column == "" {
+		column = l.Field
+	}
+
+	if l.Value != "" {
+		return fmt.Sprintf("%s, %s %q: %s", position, column, l.Value, l.Cause)
+	}
+	return fmt.Sprintf("%s, %s: %s", position, column, l.Cause)
+}
+
 // NewFieldParseError is a helper function for creating a field parse error.
 // Returns a pointer to a new FieldParseError structure.
 func NewFieldParseError(lineNo int, field string, cause error) *FieldParseError {
@@ -50,13 +115,25 @@ func NewFieldParseError(lineNo int, field string, cause error) *FieldParseError
 	}
 }
 
-// ImportCSV is a function used to import a csv file located by filename, parse
-// and convert to a Lenders slice of Lender structures.
+// ImportCSV is a function used to import a comma separated csv file located
+// by filename, parse and convert to a Lenders slice of Lender structures. The
+// first line must be a header naming the columns.
 // Returns a Lenders slice of lenders if successfully imported, or
 // the assoicated error otherwise.
 func ImportCSV(filename string) (Lenders, error) {
-	var lenders Lenders
+	return ImportCSVWithOptions(filename, ImportOptions{})
+}
 
+// ImportCSVWithOptions is a function used to import a csv file located by
+// filename using the options, such as a different delimiter. The columns are
+// found by the names in the header, so they can be in any order, can use
+// common alternative names such as "Amount" for "Available", and any columns
+// that are not needed are ignored. The Lender, Rate and Available columns are
+// required, and the Currency, Min Term, Max Term and Risk Band columns are
+// optional.
+// Returns a Lenders slice of lenders if successfully imported, or
+// the assoicated error otherwise.
+func ImportCSVWithOptions(filename string, opts ImportOptions) (Lenders, error) {
 	// Attempt to open the csv file
 	csvfile, err := os.Open(filename)
 	if err != nil {
@@ -65,41 +142,199 @@ func ImportCSV(filename string) (Lenders, error) {
 
 	defer csvfile.Close()
 
-	// Attempt to parse the file as a csv
-	reader := csv.NewReader(csvfile)
-	reader.FieldsPerRecord = csvFieldsPerRow
-	rawCSVdata, err := reader.ReadAll()
+	return ReadCSV(csvfile, opts)
+}
 
+// ReadCSV is a function used to import a csv market from r, such as standard
+// input or the body of an HTTP request, in the same way as
+// ImportCSVWithOptions. Bad rows are handled according to the ImportMode of
+// the options, but a bad header always stops the import.
+// Returns a Lenders slice of lenders if successfully imported, or
+// the assoicated error otherwise.
+func ReadCSV(r io.Reader, opts ImportOptions) (Lenders, error) {
+	s, err := newCSVStream(r, opts)
 	if err != nil {
 		return nil, err
 	}
+	return s.collect()
+}
 
-	lineNo := 1
+// newCSVStream is a private function that reads the header of a csv market
+// from r, ready to stream the rows after it.
+// Returns a pointer to the Stream, or the associated error if the header
+// cannot be used.
+func newCSVStream(r io.Reader, opts ImportOptions) (*Stream, error) {
+	// Attempt to parse the file as a csv. Every row must have the same number
+	// of fields as the header row. The lenders are copied out of each row, so
+	// the row can be reused.
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 0
+	reader.ReuseRecord = true
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
 
-	// Loop through all rows in the csv file.
-	for _, record := range rawCSVdata {
+	s := &Stream{errs: collector{mode: opts.Mode}}
 
-		// Ignore the first line which contains column headers.
-		if lineNo > 1 {
-			l := Lender{}
-			l.Name = record[csvNameIndex]
+	header, err := reader.Read()
+	if err == io.EOF {
+		s.done = true
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			// The rate should be a floating point number.
-			l.Rate, err = strconv.ParseFloat(record[csvRateIndex], 64)
-			if err != nil {
-				return nil, NewFieldParseError(lineNo, "rate", err)
+	// The header row decides which column holds each field.
+	layout, err := newCSVLayout(header)
+	if err != nil {
+		return nil, err
+	}
+
+	s.next = func() (Lender, ImportErrors, error) {
+		record, err := reader.Read()
+
+		// A malformed row, such as one with the wrong number of fields, can be
+		// skipped as the reader carries on from the next row.
+		var rowErr *csv.ParseError
+		if errors.As(err, &rowErr) {
+			return Lender{}, ImportErrors{NewFieldParseError(rowErr.StartLine, "row", rowErr)}, nil
+		}
+		if err != nil {
+			return Lender{}, nil, err
+		}
+
+		lineNo, _ := reader.FieldPos(0)
+		l, fieldErrs := layout.parse(lineNo, record)
+		return l, fieldErrs, nil
+	}
+	return s, nil
+}
+
+// csvLayout maps the fields of a lender to the columns of a csv file.
+type csvLayout struct {
+	// columns is the index of the column holding each field.
+	columns map[string]int
+	// header is the name of every column as it appears in the file.
+	header []string
+}
+
+// newCSVLayout is a private function that finds the column holding each field
+// from the header row. Headers are matched ignoring case, surrounding spaces
+// and the difference between spaces, underscores and hyphens.
+// Returns the csvLayout if every required column is found, or a
+// FieldParseError for the header otherwise.
+func newCSVLayout(header []string) (*csvLayout, error) {
+	layout := &csvLayout{
+		columns: map[string]int{},
+		header:  make([]string, len(header)),
+	}
+
+	for i, name := range header {
+		// Exports sometimes start with a byte order mark.
+		name = strings.TrimPrefix(name, "\ufeff")
+		layout.header[i] = name
+
+		column, ok := findCSVColumn(name)
+		if !ok {
+			continue
+		}
+		if _, ok := layout.columns[column.field]; ok {
+			return nil, NewFieldParseError(1, "header", fmt.Errorf("%w: %s", ErrDuplicateColumn, column.field))
+		}
+		layout.columns[column.field] = i
+	}
+
+	for _, column := range csvColumns {
+		if _, ok := layout.columns[column.field]; column.required && !ok {
+			return nil, NewFieldParseError(1, "header", fmt.Errorf("%w: %s", ErrMissingColumn, column.field))
+		}
+	}
+
+	return layout, nil
+}
+
+// findCSVColumn is a private function that looks up the column for a header.
+// Returns the csvColumn and true if the header is recognised, or false
+// otherwise.
+func findCSVColumn(header string) (csvColumn, bool) {
+	header = strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(header))
+	header = strings.Join(strings.Fields(header), " ")
+
+	for _, column := range csvColumns {
+		for _, alias := range column.aliases {
+			if header == alias {
+				return column, true
 			}
+		}
+	}
+	return csvColumn{}, false
+}
+
+// fieldError is a private function that creates the error for a field of a
+// record that cannot be parsed, with the column name and raw value.
+// Returns a pointer to the new FieldParseError.
+func (layout *csvLayout) fieldError(lineNo int, field string, record []string, cause error) *FieldParseError {
+	i := layout.columns[field]
+
+	err := NewFieldParseError(lineNo, field, cause)
+	err.Column = layout.header[i]
+	err.Value = record[i]
+	return err
+}
+
+// parse is a private function that converts a csv record into a lender.
+// Returns the Lender, and an error for every field that could not be parsed.
+func (layout *csvLayout) parse(lineNo int, record []string) (Lender, ImportErrors) {
+	var (
+		l    Lender
+		errs ImportErrors
+		err  error
+	)
 
-			// The amount availale should be an integer
-			l.Available, err = strconv.Atoi(record[csvAmountIndex])
+	l.Name = record[layout.columns["name"]]
+
+	// The rate should be a floating point number.
+	l.Rate, err = strconv.ParseFloat(record[layout.columns["rate"]], 64)
+	if err != nil {
+		errs = append(errs, layout.fieldError(lineNo, "rate", record, err))
+	}
+
+	// The amount availale should be an integer
+	l.Available, err = strconv.Atoi(record[layout.columns["available"]])
+	if err != nil {
+		errs = append(errs, layout.fieldError(lineNo, "available", record, err))
+	}
+
+	// The currency is optional, and must be supported if given.
+	if i, ok := layout.columns["currency"]; ok {
+		c, err := money.LookupCurrency(record[i])
+		if err != nil {
+			errs = append(errs, layout.fieldError(lineNo, "currency", record, err))
+		}
+		l.Currency = c.Code
+	}
+
+	// The term limits are optional, and a blank value means no limit.
+	terms := []struct {
+		field string
+		value *int
+	}{
+		{"min_term", &l.MinTerm},
+		{"max_term", &l.MaxTerm},
+	}
+	for _, term := range terms {
+		if i, ok := layout.columns[term.field]; ok && record[i] != "" {
+			*term.value, err = strconv.Atoi(record[i])
 			if err != nil {
-				return nil, NewFieldParseError(lineNo, "available", err)
+				errs = append(errs, layout.fieldError(lineNo, term.field, record, err))
 			}
-
-			lenders = append(lenders, l)
 		}
-		lineNo++
 	}
 
-	return lenders, nil
+	if i, ok := layout.columns["risk_band"]; ok {
+		l.RiskBand = strings.TrimSpace(record[i])
+	}
+
+	return l, errs
 }
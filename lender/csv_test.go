@@ -1,6 +1,10 @@
 package lender
 
 import (
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,3 +65,133 @@ func TestCSVImportWorksWithGoodData(t *testing.T) {
 	assert.Equal(t, lenders[1].Rate, 0.069)
 	assert.Equal(t, lenders[1].Available, 480)
 }
+
+func TestCSVImportErrorCanBeUnwrapped(t *testing.T) {
+	_, err := ImportCSV("test_bad_amount.csv")
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "available", parseErr.Field)
+
+	var numErr *strconv.NumError
+	assert.True(t, errors.As(err, &numErr), "Expected the cause to be reachable")
+}
+
+func TestCSVImportWorksWithCurrency(t *testing.T) {
+	lenders, err := ImportCSV("test_currency.csv")
+
+	assert.Nil(t, err, "Expected file to work but it failed")
+	assert.Equal(t, 2, len(lenders), "There should be 2 lenders")
+	assert.Equal(t, "EUR", lenders[0].Currency, "Currency codes should be upper case")
+	assert.Equal(t, "EUR", lenders[1].Currency)
+}
+
+func TestCSVImportFailsWithBadCurrency(t *testing.T) {
+	_, err := ImportCSV("test_bad_currency.csv")
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "currency", parseErr.Field)
+	assert.Equal(t, 3, parseErr.LineNo)
+}
+
+func TestCSVImportFindsColumnsByHeader(t *testing.T) {
+	assert := assert.New(t)
+
+	lenders, err := ImportCSVWithOptions("test_back_office.csv", ImportOptions{Delimiter: ';'})
+
+	assert.Nil(err, "Expected the back office export to be imported")
+	assert.Equal(2, len(lenders))
+
+	assert.Equal("Lender1", lenders[0].Name)
+	assert.Equal(0.075, lenders[0].Rate)
+	assert.Equal(640, lenders[0].Available)
+	assert.Equal("GBP", lenders[0].Currency)
+	assert.Equal(36, lenders[0].MaxTerm)
+	assert.Equal(0, lenders[0].MinTerm, "Expected a missing column to mean no limit")
+	assert.Equal("A", lenders[0].RiskBand)
+
+	assert.Equal(0, lenders[1].MaxTerm, "Expected a blank term to mean no limit")
+	assert.Equal("B", lenders[1].RiskBand)
+}
+
+func TestCSVImportFailsWithWrongDelimiter(t *testing.T) {
+	_, err := ImportCSV("test_back_office.csv")
+
+	assert.True(t, errors.Is(err, ErrMissingColumn), "Expected the columns not to be found")
+}
+
+func TestCSVImportFailsWithMissingColumn(t *testing.T) {
+	_, err := ImportCSV("test_missing_column.csv")
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "header", parseErr.Field)
+	assert.Equal(t, 1, parseErr.LineNo)
+	assert.True(t, errors.Is(err, ErrMissingColumn))
+	assert.Contains(t, err.Error(), "rate")
+}
+
+func TestCSVImportFailsWithDuplicateColumn(t *testing.T) {
+	_, err := ImportCSV("test_duplicate_column.csv")
+
+	assert.True(t, errors.Is(err, ErrDuplicateColumn), "Expected Available and Amount to clash")
+}
+
+func TestCSVImportCollectsEveryError(t *testing.T) {
+	assert := assert.New(t)
+
+	lenders, err := ImportCSVWithOptions("test_many_errors.csv", ImportOptions{Mode: ImportCollect})
+
+	assert.Nil(lenders, "Expected no lenders when collecting errors")
+
+	var errs ImportErrors
+	assert.True(errors.As(err, &errs), "Expected an ImportErrors")
+	assert.Equal(4, len(errs), "Expected every bad field to be reported")
+	assert.Equal([]int{3, 4, 5}, errs.Lines())
+
+	assert.Equal("rate", errs[0].Field)
+	assert.Equal("Rate", errs[0].Column)
+	assert.Equal("bad_rate", errs[0].Value)
+	assert.Equal("available", errs[1].Field)
+	assert.Equal("lots", errs[1].Value)
+	assert.Equal("row", errs[2].Field)
+	assert.True(errors.Is(errs[2], csv.ErrFieldCount))
+	assert.Equal("currency", errs[3].Field)
+
+	assert.Equal(strings.Join([]string{
+		"Found 4 errors in the market:",
+		`  line 3, Rate "bad_rate": strconv.ParseFloat: parsing "bad_rate": invalid syntax`,
+		`  line 3, Available "lots": strconv.Atoi: parsing "lots": invalid syntax`,
+		"  line 4, row: record on line 4: wrong number of fields",
+		`  line 5, Currency "XYZ": Unsupported currency "XYZ"`,
+	}, "\n"), err.Error())
+}
+
+func TestCSVImportLenientSkipsBadRows(t *testing.T) {
+	lenders, err := ImportCSVWithOptions("test_many_errors.csv", ImportOptions{Mode: ImportLenient})
+
+	assert.Equal(t, 2, len(lenders), "Expected the good rows to be imported")
+	assert.Equal(t, "Bob", lenders[0].Name)
+	assert.Equal(t, "John", lenders[1].Name)
+
+	var errs ImportErrors
+	assert.True(t, errors.As(err, &errs), "Expected the skipped rows to be reported")
+	assert.Equal(t, []int{3, 4, 5}, errs.Lines())
+}
+
+func TestCSVImportLenientWithGoodData(t *testing.T) {
+	lenders, err := ImportCSVWithOptions("test_good_test.csv", ImportOptions{Mode: ImportLenient})
+
+	assert.Nil(t, err, "Expected no error when no rows are skipped")
+	assert.Equal(t, 2, len(lenders))
+}
+
+func TestCSVImportStrictStopsAtFirstError(t *testing.T) {
+	_, err := ImportCSV("test_many_errors.csv")
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "rate", parseErr.Field)
+	assert.Equal(t, 3, parseErr.LineNo)
+}
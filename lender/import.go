@@ -0,0 +1,622 @@
+package lender
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eazynow/goquote/money"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrMissingField is the cause of a FieldParseError when a required field of
+// a lender is missing from a JSON or YAML market.
+var ErrMissingField = errors.New("The field is missing")
+
+// ErrNoLenders is used when a JSON or YAML market is an object or mapping
+// without a "lenders" field, such as one with the field name misspelled or a
+// single lender on its own.
+var ErrNoLenders = errors.New("The market does not have a \"lenders\" field")
+
+// Format represents a file format that a market can be imported from.
+type Format string
+
+// The market formats supported by the Import and Read functions.
+const (
+	// FormatCSV is a csv file with a header naming the columns.
+	FormatCSV Format = "csv"
+	// FormatJSON is a JSON array of lenders, or an object with a "lenders"
+	// array such as the one returned by the /market endpoint of the server.
+	FormatJSON Format = "json"
+	// FormatJSONLines is one JSON lender object per line.
+	FormatJSONLines Format = "jsonl"
+	// FormatYAML is a YAML list of lenders, or a mapping with a "lenders"
+	// list.
+	FormatYAML Format = "yaml"
+)
+
+// Formats is the list of every supported market format.
+var Formats = []Format{FormatCSV, FormatJSON, FormatJSONLines, FormatYAML}
+
+// formatExtensions maps the file extensions that are recognised to the
+// format of the file.
+var formatExtensions = map[string]Format{
+	".csv":    FormatCSV,
+	".tsv":    FormatCSV,
+	".json":   FormatJSON,
+	".jsonl":  FormatJSONLines,
+	".ndjson": FormatJSONLines,
+	".yaml":   FormatYAML,
+	".yml":    FormatYAML,
+}
+
+// ParseFormat is a function used to convert the name of a market format,
+// such as one passed on the command line, into a Format.
+// Returns the Format if the name is recognised, or an error otherwise.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown market format %q", name)
+}
+
+// ImportMode represents how bad rows in a market are handled.
+type ImportMode int
+
+const (
+	// ImportStrict stops at the first bad row and returns its
+	// FieldParseError. This is the default.
+	ImportStrict ImportMode = iota
+	// ImportCollect reads the whole market and returns an ImportErrors
+	// listing every bad field of every bad row.
+	ImportCollect
+	// ImportLenient skips bad rows. The lenders from the good rows are
+	// returned along with an ImportErrors listing the rows that were skipped,
+	// or a nil error if there were none.
+	ImportLenient
+)
+
+// ImportErrors is an error listing every bad field found while importing a
+// market, in the order they appear.
+type ImportErrors []*FieldParseError
+
+// The Error function is used to satisfy the error interface.
+// Returns a string listing every error on its own line.
+func (e ImportErrors) Error() string {
+	noun := "errors"
+	if len(e) == 1 {
+		noun = "error"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d %s in the market:", len(e), noun)
+
+	for _, err := range e {
+		b.WriteString("\n  ")
+		b.WriteString(err.summary())
+	}
+	return b.String()
+}
+
+// Unwrap returns every error so they can be checked using errors.Is and
+// errors.As.
+func (e ImportErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Lines is a function used to get the lines or records that had errors.
+// Returns the position of each bad row once, in order.
+func (e ImportErrors) Lines() []int {
+	var lines []int
+	for _, err := range e {
+		line := err.LineNo
+		if line == 0 {
+			line = err.Record
+		}
+		if len(lines) == 0 || lines[len(lines)-1] != line {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// collector gathers the errors in the rows of a market according to the
+// ImportMode.
+type collector struct {
+	mode ImportMode
+	errs ImportErrors
+}
+
+// add is a private function that records the errors for a bad row.
+// Returns true if the import should stop.
+func (c *collector) add(errs ...*FieldParseError) bool {
+	c.errs = append(c.errs, errs...)
+	return c.mode == ImportStrict
+}
+
+// result is a private function that decides what an import returns once
+// every row has been read.
+// Returns the lenders and the error for the ImportMode.
+func (c *collector) result(lenders Lenders) (Lenders, error) {
+	switch {
+	case len(c.errs) == 0:
+		return lenders, nil
+	case c.mode == ImportStrict:
+		return nil, c.errs[0]
+	case c.mode == ImportCollect:
+		return nil, c.errs
+	}
+	return lenders, c.errs
+}
+
+// ImportOptions holds the settings used when importing a market. The zero
+// value detects the format, imports csv files separated by commas and stops
+// at the first bad row.
+type ImportOptions struct {
+	// Format is the format of the market. If not set, it is detected from the
+	// file extension, or from the content if the extension is not known.
+	Format Format
+	// Delimiter is the character that separates the fields of a csv file. If
+	// not set, a comma is used, or a tab for files with a .tsv extension.
+	Delimiter rune
+	// Mode is how bad rows are handled.
+	Mode ImportMode
+}
+
+// Import is a function used to import a market file located by filename in
+// any of the supported formats.
+// Returns a Lenders slice of lenders if successfully imported, or the
+// associated error otherwise.
+func Import(filename string, opts ImportOptions) (Lenders, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if opts.Format == "" {
+		opts.Format = formatExtensions[ext]
+	}
+	if ext == ".tsv" && opts.Delimiter == 0 {
+		opts.Delimiter = '\t'
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Read(file, opts)
+}
+
+// Read is a function used to import a market from r, such as standard input,
+// the body of an HTTP request or a test fixture, in any of the supported
+// formats. If the options do not give the format, it is detected from the
+// content.
+// Returns a Lenders slice of lenders if successfully imported, or the
+// associated error otherwise.
+func Read(r io.Reader, opts ImportOptions) (Lenders, error) {
+	if opts.Format == "" {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		opts.Format = DetectFormat(data)
+		r = bytes.NewReader(data)
+	}
+
+	switch opts.Format {
+	case FormatCSV:
+		return ReadCSV(r, opts)
+	case FormatJSON:
+		return ReadJSON(r, opts)
+	case FormatJSONLines:
+		return ReadJSONLines(r, opts)
+	case FormatYAML:
+		return ReadYAML(r, opts)
+	}
+
+	return nil, fmt.Errorf("Unknown market format %q", opts.Format)
+}
+
+// DetectFormat is a function used to work out the format of a market from
+// its content. JSON starts with a bracket or brace, YAML with a list item, a
+// document marker, a comment or a "lenders:" key, and anything else is
+// treated as csv.
+// Returns the Format of the market.
+func DetectFormat(data []byte) Format {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\ufeff")), " \t\r\n")
+
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		return FormatJSON
+	case bytes.HasPrefix(data, []byte("{")):
+		// A single object is a JSON market if it holds the lenders, and
+		// otherwise the first of a series of lines.
+		var market map[string]json.RawMessage
+		if json.Unmarshal(data, &market) == nil {
+			if _, ok := market["lenders"]; ok {
+				return FormatJSON
+			}
+		}
+		return FormatJSONLines
+	case bytes.HasPrefix(data, []byte("- ")),
+		bytes.HasPrefix(data, []byte("---")),
+		bytes.HasPrefix(data, []byte("#")),
+		bytes.HasPrefix(data, []byte("lenders:")):
+		return FormatYAML
+	}
+
+	return FormatCSV
+}
+
+// ReadJSON is a function used to import a JSON market from r. The market is
+// either an array of lenders or an object with a "lenders" array, and each
+// lender is an object with the same fields as the Lender structure. The
+// name, rate and available fields are required. Bad lenders are handled
+// according to the ImportMode of the options.
+// Returns a Lenders slice of lenders if successfully imported, or the
+// associated error otherwise.
+func ReadJSON(r io.Reader, opts ImportOptions) (Lenders, error) {
+	return newJSONStream(r, opts).collect()
+}
+
+// newJSONStream is a private function that streams the lenders of a JSON
+// market from r, decoding one lender at a time rather than the whole market.
+// Returns a pointer to the Stream.
+func newJSONStream(r io.Reader, opts ImportOptions) *Stream {
+	var (
+		dec     = json.NewDecoder(r)
+		started bool
+		wrapped bool
+		record  int
+	)
+
+	s := &Stream{errs: collector{mode: opts.Mode}}
+	s.next = func() (Lender, ImportErrors, error) {
+		if !started {
+			started = true
+
+			var (
+				found bool
+				err   error
+			)
+			wrapped, found, err = openJSONMarket(dec)
+			if err != nil {
+				return Lender{}, nil, err
+			}
+			if !found {
+				return Lender{}, nil, closeJSONMarket(dec, false)
+			}
+		}
+
+		if !dec.More() {
+			// Read the end of the lenders array, and of the object around it.
+			if _, err := dec.Token(); err != nil {
+				return Lender{}, nil, err
+			}
+			return Lender{}, nil, closeJSONMarket(dec, wrapped)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return Lender{}, nil, err
+		}
+		record++
+
+		l, recordErrs := parseJSONRecord(raw)
+		for _, err := range recordErrs {
+			err.Record = record
+		}
+		return l, recordErrs, nil
+	}
+	return s
+}
+
+// openJSONMarket is a private function that reads a JSON market up to the
+// start of the lenders array, which is either the market itself or the
+// "lenders" field of an object. Any other fields of the object before the
+// lenders are skipped.
+// Returns whether the lenders are in an object and whether they were found,
+// or the associated error if the JSON is not a market, such as ErrNoLenders
+// for an object without a "lenders" field. An object whose lenders are null
+// has been read in full.
+func openJSONMarket(dec *json.Decoder) (wrapped, found bool, err error) {
+	token, err := dec.Token()
+	if err == io.EOF {
+		return false, false, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	switch token {
+	case json.Delim('['):
+		return false, true, nil
+	case json.Delim('{'):
+	default:
+		return false, false, errors.New("The JSON market must be an array of lenders, or an object with a \"lenders\" array")
+	}
+
+	hasLenders := false
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return false, false, err
+		}
+
+		if key == "lenders" {
+			hasLenders = true
+			token, err := dec.Token()
+			if err != nil {
+				return false, false, err
+			}
+			if token == json.Delim('[') {
+				return true, true, nil
+			}
+			if token != nil {
+				return false, false, errors.New("The \"lenders\" field of a JSON market must be an array")
+			}
+			continue
+		}
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return false, false, err
+		}
+	}
+
+	if !hasLenders {
+		return false, false, ErrNoLenders
+	}
+
+	// The lenders of the object are null, so read the end of it.
+	_, err = dec.Token()
+	return false, false, err
+}
+
+// closeJSONMarket is a private function that reads the rest of a JSON market
+// once the lenders array has ended, skipping any other fields of the object
+// the array is in.
+// Returns io.EOF if the market ends correctly, or the associated error
+// otherwise.
+func closeJSONMarket(dec *json.Decoder, wrapped bool) error {
+	if wrapped {
+		for dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	// Nothing can follow the market.
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("Unexpected data after the end of the JSON market")
+		}
+		return err
+	}
+	return io.EOF
+}
+
+// ReadJSONLines is a function used to import a JSON Lines market from r, with
+// one lender object per line as in ReadJSON. Blank lines are ignored.
+// Returns a Lenders slice of lenders if successfully imported, or the
+// associated error otherwise.
+func ReadJSONLines(r io.Reader, opts ImportOptions) (Lenders, error) {
+	return newJSONLinesStream(r, opts).collect()
+}
+
+// newJSONLinesStream is a private function that streams the lenders of a JSON
+// Lines market from r, one line at a time.
+// Returns a pointer to the Stream.
+func newJSONLinesStream(r io.Reader, opts ImportOptions) *Stream {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	lineNo := 0
+
+	s := &Stream{errs: collector{mode: opts.Mode}}
+	s.next = func() (Lender, ImportErrors, error) {
+		for scanner.Scan() {
+			lineNo++
+
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			l, recordErrs := parseJSONRecord(line)
+			for _, err := range recordErrs {
+				err.LineNo = lineNo
+			}
+			return l, recordErrs, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return Lender{}, nil, err
+		}
+		return Lender{}, nil, io.EOF
+	}
+	return s
+}
+
+// ReadYAML is a function used to import a YAML market from r. The market is
+// either a list of lenders or a mapping with a "lenders" list, and each
+// lender has the same fields as in ReadJSON.
+// Returns a Lenders slice of lenders if successfully imported, or the
+// associated error otherwise.
+func ReadYAML(r io.Reader, opts ImportOptions) (Lenders, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var market yamlMarket
+	if err := yaml.Unmarshal(data, &market); err != nil {
+		return nil, err
+	}
+
+	lenders := make(Lenders, 0, len(market))
+	errs := collector{mode: opts.Mode}
+	for i, record := range market {
+		var (
+			l          Lender
+			recordErrs ImportErrors
+		)
+		if record.err != nil {
+			recordErrs = ImportErrors{NewFieldParseError(0, "lender", record.err)}
+		} else {
+			l, recordErrs = record.lender()
+		}
+
+		if len(recordErrs) > 0 {
+			for _, err := range recordErrs {
+				err.Record = i + 1
+			}
+			if errs.add(recordErrs...) {
+				break
+			}
+			continue
+		}
+		lenders = append(lenders, l)
+	}
+
+	return errs.result(lenders)
+}
+
+// lenderRecord is a lender as written in a JSON or YAML market. The required
+// fields are pointers so that a missing field can be told apart from a zero.
+type lenderRecord struct {
+	Name      *string  `json:"name" yaml:"name"`
+	Rate      *float64 `json:"rate" yaml:"rate"`
+	Available *int     `json:"available" yaml:"available"`
+	Currency  string   `json:"currency" yaml:"currency"`
+	MinTerm   int      `json:"min_term" yaml:"min_term"`
+	MaxTerm   int      `json:"max_term" yaml:"max_term"`
+	RiskBand  string   `json:"risk_band" yaml:"risk_band"`
+}
+
+// lender is a private function that converts the record into a Lender.
+// Returns the Lender, and an error without a position for every field that
+// is missing or invalid.
+func (r lenderRecord) lender() (Lender, ImportErrors) {
+	var (
+		l    Lender
+		errs ImportErrors
+	)
+
+	if r.Name == nil {
+		errs = append(errs, NewFieldParseError(0, "name", ErrMissingField))
+	} else {
+		l.Name = *r.Name
+	}
+
+	if r.Rate == nil {
+		errs = append(errs, NewFieldParseError(0, "rate", ErrMissingField))
+	} else {
+		l.Rate = *r.Rate
+	}
+
+	if r.Available == nil {
+		errs = append(errs, NewFieldParseError(0, "available", ErrMissingField))
+	} else {
+		l.Available = *r.Available
+	}
+
+	l.MinTerm = r.MinTerm
+	l.MaxTerm = r.MaxTerm
+	l.RiskBand = strings.TrimSpace(r.RiskBand)
+
+	// The currency is optional, and must be supported if given.
+	if r.Currency != "" {
+		c, err := money.LookupCurrency(r.Currency)
+		if err != nil {
+			err := NewFieldParseError(0, "currency", err)
+			err.Value = r.Currency
+			errs = append(errs, err)
+		}
+		l.Currency = c.Code
+	}
+
+	return l, errs
+}
+
+// parseJSONRecord is a private function that converts a JSON lender object
+// into a Lender.
+// Returns the Lender, and an error without a position for every field that
+// is missing or invalid.
+func parseJSONRecord(data []byte) (Lender, ImportErrors) {
+	var record lenderRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		field := "lender"
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			field = typeErr.Field
+		}
+		return Lender{}, ImportErrors{NewFieldParseError(0, field, err)}
+	}
+
+	return record.lender()
+}
+
+// yamlLender holds a lender from a YAML market along with any error decoding
+// it, so that the error can be reported against the right record.
+type yamlLender struct {
+	lenderRecord
+	err error
+}
+
+// UnmarshalYAML is used to satisfy the yaml.Unmarshaler interface.
+func (y *yamlLender) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	y.err = unmarshal(&y.lenderRecord)
+	return nil
+}
+
+// yamlMarket is the lenders in a YAML market, which can be a list or a
+// mapping with a "lenders" list. A mapping without a "lenders" field is
+// rejected with ErrNoLenders.
+type yamlMarket []yamlLender
+
+// UnmarshalYAML is used to satisfy the yaml.Unmarshaler interface.
+func (m *yamlMarket) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []yamlLender
+	if err := unmarshal(&list); err == nil {
+		*m = list
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+	if _, ok := fields["lenders"]; !ok {
+		return ErrNoLenders
+	}
+
+	var market struct {
+		Lenders []yamlLender `yaml:"lenders"`
+	}
+	if err := unmarshal(&market); err != nil {
+		return err
+	}
+
+	*m = market.Lenders
+	return nil
+}
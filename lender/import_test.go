@@ -0,0 +1,192 @@
+package lender
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertGoodMarket checks the lenders match the two lenders in the good test
+// markets.
+func assertGoodMarket(t *testing.T, lenders Lenders, err error) {
+	assert.Nil(t, err, "Expected the market to be imported")
+	if !assert.Equal(t, 2, len(lenders), "There should be 2 lenders") {
+		return
+	}
+
+	assert.Equal(t, "Lender1", lenders[0].Name)
+	assert.Equal(t, 0.075, lenders[0].Rate)
+	assert.Equal(t, 640, lenders[0].Available)
+
+	assert.Equal(t, "Lender2", lenders[1].Name)
+	assert.Equal(t, 0.069, lenders[1].Rate)
+	assert.Equal(t, 480, lenders[1].Available)
+}
+
+func TestImportDetectsFormatFromExtension(t *testing.T) {
+	for _, filename := range []string{
+		"test_good_test.csv",
+		"test_market.tsv",
+		"test_market.json",
+		"test_market_wrapped.json",
+		"test_market.jsonl",
+		"test_market.yaml",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			lenders, err := Import(filename, ImportOptions{})
+			assertGoodMarket(t, lenders, err)
+		})
+	}
+}
+
+func TestImportFailsOnNotFound(t *testing.T) {
+	_, err := Import("unknownfile.json", ImportOptions{})
+
+	assert.NotNil(t, err, "Expected an error as the file cannot be found")
+}
+
+func TestImportReadsOptionalFields(t *testing.T) {
+	lenders, err := Import("test_market.json", ImportOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "GBP", lenders[0].Currency, "Currency codes should be upper case")
+	assert.Equal(t, 24, lenders[1].MaxTerm)
+	assert.Equal(t, "B", lenders[1].RiskBand)
+
+	lenders, err = Import("test_market.yaml", ImportOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 12, lenders[1].MinTerm)
+}
+
+func TestReadDetectsFormatFromContent(t *testing.T) {
+	for _, filename := range []string{
+		"test_good_test.csv",
+		"test_market.json",
+		"test_market_wrapped.json",
+		"test_market.jsonl",
+		"test_market.yaml",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			data, err := os.ReadFile(filename)
+			assert.Nil(t, err)
+
+			lenders, err := Read(strings.NewReader(string(data)), ImportOptions{})
+			assertGoodMarket(t, lenders, err)
+		})
+	}
+}
+
+func TestReadUsesFormatOption(t *testing.T) {
+	_, err := Read(strings.NewReader("Lender,Rate,Available\n"), ImportOptions{Format: FormatJSON})
+
+	assert.NotNil(t, err, "Expected csv not to be read as JSON")
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(FormatJSON, DetectFormat([]byte(" [\n]")))
+	assert.Equal(FormatJSON, DetectFormat([]byte(`{"lenders": []}`)))
+	assert.Equal(FormatJSONLines, DetectFormat([]byte(`{"name": "Bob", "rate": 0.07, "available": 100}`)))
+	assert.Equal(FormatYAML, DetectFormat([]byte("- name: Bob\n")))
+	assert.Equal(FormatYAML, DetectFormat([]byte("lenders:\n")))
+	assert.Equal(FormatCSV, DetectFormat([]byte("Lender,Rate,Available\n")))
+	assert.Equal(FormatCSV, DetectFormat(nil))
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("JSONL")
+
+	assert.Nil(t, err)
+	assert.Equal(t, FormatJSONLines, format)
+
+	_, err = ParseFormat("xml")
+	assert.NotNil(t, err, "Expected an error for an unknown format")
+}
+
+func TestReadJSONLinesFailsWithBadRate(t *testing.T) {
+	_, err := Import("test_bad_rate.jsonl", ImportOptions{})
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "rate", parseErr.Field)
+	assert.Equal(t, 2, parseErr.LineNo)
+}
+
+func TestReadJSONFailsWithMissingField(t *testing.T) {
+	_, err := ReadJSON(strings.NewReader(`[{"name": "Bob", "rate": 0.07, "available": 100}, {"name": "Jane", "rate": 0.06}]`), ImportOptions{})
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "available", parseErr.Field)
+	assert.Equal(t, 2, parseErr.Record)
+	assert.True(t, errors.Is(err, ErrMissingField))
+	assert.Equal(t, "Error unmarshalling available field of record 2. Cause: The field is missing", err.Error())
+}
+
+func TestReadJSONFailsWithBadCurrency(t *testing.T) {
+	_, err := ReadJSON(strings.NewReader(`[{"name": "Bob", "rate": 0.07, "available": 100, "currency": "XYZ"}]`), ImportOptions{})
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "currency", parseErr.Field)
+}
+
+func TestReadYAMLFailsWithMissingField(t *testing.T) {
+	_, err := Import("test_missing_rate.yaml", ImportOptions{})
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, "rate", parseErr.Field)
+	assert.Equal(t, 2, parseErr.Record)
+}
+
+func TestReadYAMLFailsWithBadType(t *testing.T) {
+	_, err := ReadYAML(strings.NewReader("- name: Bob\n  rate: bad_rate\n  available: 100\n"), ImportOptions{})
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, 1, parseErr.Record)
+	assert.Contains(t, err.Error(), "bad_rate")
+}
+
+func TestReadYAMLFailsWithoutLendersField(t *testing.T) {
+	for _, market := range []string{
+		"lendrs:\n  - name: Bob\n    rate: 0.075\n    available: 640\n",
+		"name: Bob\nrate: 0.075\navailable: 640\n",
+	} {
+		_, err := ReadYAML(strings.NewReader(market), ImportOptions{})
+
+		assert.True(t, errors.Is(err, ErrNoLenders), "Expected %q to have no lenders field", market)
+	}
+
+	lenders, err := ReadYAML(strings.NewReader("currency: GBP\nlenders:\n  - name: Bob\n    rate: 0.075\n    available: 640\n"), ImportOptions{})
+	assert.Nil(t, err, "Expected a mapping with lenders to be read")
+	assert.Equal(t, 1, len(lenders))
+}
+
+func TestReadJSONCollectsEveryError(t *testing.T) {
+	_, err := ReadJSON(strings.NewReader(`[{"name": "Bob"}, {"name": "Jane", "rate": 0.06, "available": 100}, {"rate": "x"}]`),
+		ImportOptions{Mode: ImportCollect})
+
+	var errs ImportErrors
+	assert.True(t, errors.As(err, &errs), "Expected an ImportErrors")
+	assert.Equal(t, []int{1, 3}, errs.Lines())
+	assert.Equal(t, 3, len(errs), "Expected both missing fields of the first lender")
+	assert.Equal(t, "record 1, rate: The field is missing", errs[0].summary())
+}
+
+func TestReadJSONLinesLenientSkipsBadLines(t *testing.T) {
+	lenders, err := Import("test_bad_rate.jsonl", ImportOptions{Mode: ImportLenient})
+
+	assert.Equal(t, 1, len(lenders))
+	assert.Equal(t, "Lender1", lenders[0].Name)
+
+	var errs ImportErrors
+	assert.True(t, errors.As(err, &errs), "Expected the skipped line to be reported")
+	assert.Equal(t, []int{2}, errs.Lines())
+}
@@ -1,10 +1,73 @@
 package lender
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eazynow/goquote/money"
+)
+
+// ErrMixedCurrencies is returned when lenders in the same market lend in
+// different currencies.
+var ErrMixedCurrencies = errors.New("The market contains lenders in more than one currency")
+
 // Lender is a structure representing an individual lender.
 type Lender struct {
-	Name      string
-	Rate      float64
-	Available int
+	Name      string  `json:"name" yaml:"name"`
+	Rate      float64 `json:"rate" yaml:"rate"`
+	Available int     `json:"available" yaml:"available"`
+	// Currency is the code of the currency the lender lends in. An empty code
+	// means the money.DefaultCurrency.
+	Currency string `json:"currency,omitempty" yaml:"currency,omitempty"`
+	// MinTerm is the shortest loan in months the lender will fund. Zero means
+	// there is no minimum.
+	MinTerm int `json:"min_term,omitempty" yaml:"min_term,omitempty"`
+	// MaxTerm is the longest loan in months the lender will fund. Zero means
+	// there is no maximum.
+	MaxTerm int `json:"max_term,omitempty" yaml:"max_term,omitempty"`
+	// RiskBand is the risk band the lender is prepared to lend in, as used by
+	// the lending back office. It is informational only.
+	RiskBand string `json:"risk_band,omitempty" yaml:"risk_band,omitempty"`
+}
+
+// Lends is a function used to determine whether a lender will fund a loan
+// over term months.
+// Returns true if the term is within the lender's term limits.
+func (l *Lender) Lends(term int) bool {
+	if l.MinTerm > 0 && term < l.MinTerm {
+		return false
+	}
+	return l.MaxTerm == 0 || term <= l.MaxTerm
+}
+
+// Before is a function used to compare lenders in order of preference, the
+// cheapest rate first and, for the same rate, the most available first.
+// Returns true if the lender should be drawn from before other.
+func (l *Lender) Before(other *Lender) bool {
+	if l.Rate != other.Rate {
+		// Base the sorting on the lowest rate.
+		return l.Rate < other.Rate
+	}
+	// In the instance of the same rate, sort by who has more funds available
+	// to reduce number of lenders
+	return l.Available > other.Available
+}
+
+// CurrencyCode is a function used to get the code of the currency the lender
+// lends in.
+// Returns the upper case currency code, or the money.DefaultCurrency if none
+// is set.
+func (l *Lender) CurrencyCode() string {
+	if l.Currency == "" {
+		return money.DefaultCurrency
+	}
+	return strings.ToUpper(l.Currency)
 }
 
 // Borrow is a function used to determine how much a lender can lend. The
@@ -34,16 +97,7 @@ func (slice Lenders) Len() int {
 // less and therefore higher in the list. Used to satisfy the sort interface.
 // Returns true if i should be ranked higher than j in the sort.
 func (slice Lenders) Less(i, j int) bool {
-
-	if slice[i].Rate != slice[j].Rate {
-		// Base the sorting on the lowest rate.
-		return slice[i].Rate < slice[j].Rate
-	} else {
-		// In the instance of the same rate, sort by who has more
-		// funds available to reduce number of lenders
-		return slice[i].Available > slice[j].Available
-	}
-
+	return slice[i].Before(&slice[j])
 }
 
 // Swap switches the position of lender at index i with lender at index j
@@ -51,3 +105,94 @@ func (slice Lenders) Less(i, j int) bool {
 func (slice Lenders) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
+
+// Sorted is a function used to get the lenders in order of preference,
+// cheapest rate first, without changing the order of the slice it is called
+// on. Lenders that compare equal keep their original order, so the result is
+// the same every time.
+// Returns a sorted copy of the slice.
+func (slice Lenders) Sorted() Lenders {
+	sorted := make(Lenders, len(slice))
+	copy(sorted, slice)
+	sort.Stable(sorted)
+	return sorted
+}
+
+// ForTerm is a function used to get the lenders that will fund a loan over
+// term months, leaving the slice it is called on unchanged.
+// Returns the lenders whose term limits allow the term, in the same order.
+func (slice Lenders) ForTerm(term int) Lenders {
+	var lenders Lenders
+	for _, l := range slice {
+		if l.Lends(term) {
+			lenders = append(lenders, l)
+		}
+	}
+	return lenders
+}
+
+// Currency is a function used to find the currency that every lender in the
+// slice lends in.
+// Returns the currency code, or ErrMixedCurrencies if the lenders do not all
+// lend in the same currency. An empty slice returns the
+// money.DefaultCurrency.
+func (slice Lenders) Currency() (string, error) {
+	currency := ""
+	for _, l := range slice {
+		code := l.CurrencyCode()
+		if currency != "" && code != currency {
+			return "", ErrMixedCurrencies
+		}
+		currency = code
+	}
+
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	return currency, nil
+}
+
+// Hash is a function used to fingerprint the lenders, so that it is possible
+// to prove later which market a quote was calculated from. Every field of
+// every lender is included, in the order of the slice.
+// Returns the sha256 hash of the lenders as a hex string.
+func (slice Lenders) Hash() string {
+	f := NewFingerprint()
+	for _, l := range slice {
+		f.Add(l)
+	}
+	return f.Sum()
+}
+
+// Fingerprint is a structure used to build the Hash of a market one lender at
+// a time, such as while reading a Stream, without holding every lender.
+type Fingerprint struct {
+	h hash.Hash
+}
+
+// NewFingerprint is a helper function for creating an empty fingerprint.
+// Returns a pointer to the new Fingerprint.
+func NewFingerprint() *Fingerprint {
+	return &Fingerprint{h: sha256.New()}
+}
+
+// Add is a function used to include the next lender of the market in the
+// fingerprint. The optional term limits and risk band are only included when
+// set, so markets without them keep the same hash.
+func (f *Fingerprint) Add(l Lender) {
+	fmt.Fprintf(f.h, "%q,%s,%d,%q",
+		l.Name,
+		strconv.FormatFloat(l.Rate, 'g', -1, 64),
+		l.Available,
+		strings.ToUpper(l.Currency))
+	if l.MinTerm != 0 || l.MaxTerm != 0 || l.RiskBand != "" {
+		fmt.Fprintf(f.h, ",%d,%d,%q", l.MinTerm, l.MaxTerm, l.RiskBand)
+	}
+	fmt.Fprintln(f.h)
+}
+
+// Sum is a function used to get the fingerprint of the lenders added so far.
+// Returns the same sha256 hex string as Hash would for the same lenders.
+func (f *Fingerprint) Sum() string {
+	return hex.EncodeToString(f.h.Sum(nil))
+}
@@ -4,6 +4,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/eazynow/goquote/money"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,9 +43,9 @@ func TestBorrowWorksWhenNotEnoughFunds(t *testing.T) {
 func TestLendersSortWorksOnRate(t *testing.T) {
 	var lenders Lenders
 
-	low := Lender{"low", 0.03, 100}
-	mid := Lender{"mid", 0.05, 100}
-	high := Lender{"high", 0.08, 100}
+	low := Lender{Name: "low", Rate: 0.03, Available: 100}
+	mid := Lender{Name: "mid", Rate: 0.05, Available: 100}
+	high := Lender{Name: "high", Rate: 0.08, Available: 100}
 
 	lenders = append(lenders, mid)
 	lenders = append(lenders, high)
@@ -62,9 +63,9 @@ func TestLendersSortWorksOnRate(t *testing.T) {
 func TestLendersSortWorksOnAvailable(t *testing.T) {
 	var lenders Lenders
 
-	low := Lender{"low", 0.03, 100}
-	mid := Lender{"mid", 0.05, 200}
-	high := Lender{"high", 0.05, 100}
+	low := Lender{Name: "low", Rate: 0.03, Available: 100}
+	mid := Lender{Name: "mid", Rate: 0.05, Available: 200}
+	high := Lender{Name: "high", Rate: 0.05, Available: 100}
 
 	lenders = append(lenders, mid)
 	lenders = append(lenders, high)
@@ -78,3 +79,106 @@ func TestLendersSortWorksOnAvailable(t *testing.T) {
 	assert.Equal(t, high, lenders[2], "high lender should be third as he has smallest pool")
 
 }
+
+func TestLendersCurrencyDefaults(t *testing.T) {
+	lenders := Lenders{{Name: "a"}, {Name: "b", Currency: "gbp"}}
+
+	currency, err := lenders.Currency()
+
+	assert.Nil(t, err, "Expected lenders without a currency to use the default")
+	assert.Equal(t, money.DefaultCurrency, currency)
+}
+
+func TestLendersCurrencyFailsWhenMixed(t *testing.T) {
+	lenders := Lenders{{Name: "a", Currency: "EUR"}, {Name: "b", Currency: "USD"}}
+
+	_, err := lenders.Currency()
+
+	assert.Equal(t, ErrMixedCurrencies, err, "Expected an error as the currencies differ")
+}
+
+func TestLendersSortedLeavesSliceUnchanged(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders Lenders
+	lenders = append(lenders, Lender{Name: "Dear", Rate: 0.09, Available: 100})
+	lenders = append(lenders, Lender{Name: "First", Rate: 0.05, Available: 100})
+	lenders = append(lenders, Lender{Name: "Second", Rate: 0.05, Available: 100})
+
+	sorted := lenders.Sorted()
+
+	assert.Equal("First", sorted[0].Name, "Expected the cheapest lender first")
+	assert.Equal("Second", sorted[1].Name, "Expected equal lenders to keep their order")
+	assert.Equal("Dear", sorted[2].Name)
+	assert.Equal("Dear", lenders[0].Name, "Expected the original slice to be unchanged")
+}
+
+func TestLendersHash(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders Lenders
+	lenders = append(lenders, Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, Lender{Name: "Bob", Rate: 0.069, Available: 200})
+
+	hash := lenders.Hash()
+	assert.Equal(64, len(hash), "Expected a sha256 hex string")
+	assert.Equal(hash, lenders.Hash(), "Expected the hash to be repeatable")
+
+	changed := lenders.Sorted()
+	changed[1].Available = 199
+	assert.NotEqual(hash, changed.Hash(), "Expected any change to the market to change the hash")
+
+	reordered := Lenders{lenders[1], lenders[0]}
+	assert.NotEqual(hash, reordered.Hash(), "Expected the order of the market to be part of the hash")
+}
+
+func TestLenderLends(t *testing.T) {
+	assert := assert.New(t)
+
+	l := Lender{Name: "Jane", MinTerm: 12, MaxTerm: 36}
+	assert.False(l.Lends(6), "Expected a term below the minimum to be refused")
+	assert.True(l.Lends(12))
+	assert.True(l.Lends(36))
+	assert.False(l.Lends(48), "Expected a term above the maximum to be refused")
+
+	l = Lender{Name: "Bob"}
+	assert.True(l.Lends(1), "Expected no limits by default")
+	assert.True(l.Lends(120))
+}
+
+func TestLendersForTerm(t *testing.T) {
+	lenders := Lenders{
+		{Name: "Jane", MaxTerm: 24},
+		{Name: "Bob"},
+		{Name: "Sam", MinTerm: 36},
+	}
+
+	eligible := lenders.ForTerm(36)
+
+	assert.Equal(t, 2, len(eligible))
+	assert.Equal(t, "Bob", eligible[0].Name)
+	assert.Equal(t, "Sam", eligible[1].Name)
+	assert.Equal(t, 3, len(lenders), "Expected the slice to be left unchanged")
+}
+
+func TestLendersHashIncludesTermLimits(t *testing.T) {
+	lenders := Lenders{{Name: "Jane", Rate: 0.051, Available: 1000}}
+	limited := Lenders{{Name: "Jane", Rate: 0.051, Available: 1000, MaxTerm: 24}}
+
+	assert.NotEqual(t, lenders.Hash(), limited.Hash())
+}
+
+func TestLenderBefore(t *testing.T) {
+	bob := Lender{Name: "Bob", Rate: 0.069, Available: 480}
+	jane := Lender{Name: "Jane", Rate: 0.075, Available: 640}
+	fred := Lender{Name: "Fred", Rate: 0.069, Available: 520}
+
+	assert.True(t, bob.Before(&jane), "Expected the cheaper lender first")
+	assert.True(t, fred.Before(&bob), "Expected the lender with more available first at the same rate")
+	assert.False(t, bob.Before(&bob), "Expected a lender not to come before itself")
+}
+
+func TestLenderCurrencyCode(t *testing.T) {
+	assert.Equal(t, money.DefaultCurrency, (&Lender{}).CurrencyCode())
+	assert.Equal(t, "EUR", (&Lender{Currency: "eur"}).CurrencyCode())
+}
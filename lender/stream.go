@@ -0,0 +1,188 @@
+package lender
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrStreamFormat is returned when a market in a format that cannot be read
+// one lender at a time, such as YAML, is opened as a Stream.
+var ErrStreamFormat = errors.New("The market format cannot be streamed")
+
+// streamPeek is how much of a market is looked at to detect its format when
+// streaming.
+const streamPeek = 4096
+
+// Stream is a structure used to read the lenders of a market one at a time,
+// so that a market with millions of lenders can be used without holding all
+// of them in memory. Streams are read like a bufio.Scanner:
+//
+//	for stream.Next() {
+//		l := stream.Lender()
+//		...
+//	}
+//	if err := stream.Err(); err != nil {
+//		...
+//	}
+//
+// Bad rows are handled according to the ImportMode of the options. In
+// ImportStrict mode the stream stops at the first bad row, and in the other
+// modes bad rows are skipped and listed by Err once the stream has ended. The
+// lenders read in ImportCollect mode should not be used if Err returns an
+// error.
+type Stream struct {
+	next   func() (Lender, ImportErrors, error)
+	closer io.Closer
+	errs   collector
+	lender Lender
+	err    error
+	done   bool
+}
+
+// NewStream is a function used to stream a csv, JSON or JSON Lines market
+// from r. If the options do not give the format, it is detected from the
+// start of the content.
+// Returns a pointer to the Stream if the market can be streamed, or the
+// associated error, such as a FieldParseError for a bad csv header or
+// ErrStreamFormat, otherwise.
+func NewStream(r io.Reader, opts ImportOptions) (*Stream, error) {
+	if opts.Format == "" {
+		buffered := bufio.NewReaderSize(r, streamPeek)
+		opts.Format = detectStreamFormat(buffered)
+		r = buffered
+	}
+
+	switch opts.Format {
+	case FormatCSV:
+		return newCSVStream(r, opts)
+	case FormatJSON:
+		return newJSONStream(r, opts), nil
+	case FormatJSONLines:
+		return newJSONLinesStream(r, opts), nil
+	case FormatYAML:
+		return nil, fmt.Errorf("%w: %s", ErrStreamFormat, opts.Format)
+	}
+
+	return nil, fmt.Errorf("Unknown market format %q", opts.Format)
+}
+
+// OpenStream is a function used to stream the market file located by
+// filename, working out the format and delimiter from the file extension in
+// the same way as Import. The Stream must be closed once it is finished with.
+// Returns a pointer to the Stream if the market can be streamed, or the
+// associated error otherwise.
+func OpenStream(filename string, opts ImportOptions) (*Stream, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if opts.Format == "" {
+		opts.Format = formatExtensions[ext]
+	}
+	if ext == ".tsv" && opts.Delimiter == 0 {
+		opts.Delimiter = '\t'
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewStream(file, opts)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s.closer = file
+	return s, nil
+}
+
+// detectStreamFormat is a private function that works out the format of a
+// market from the start of its content, without consuming it. Only the first
+// line is used to tell a JSON object holding the lenders from JSON Lines, as
+// the whole object cannot be read ahead.
+// Returns the Format of the market.
+func detectStreamFormat(r *bufio.Reader) Format {
+	start, _ := r.Peek(streamPeek)
+
+	format := DetectFormat(start)
+	if format == FormatJSONLines {
+		line, _, _ := strings.Cut(string(start), "\n")
+		if !json.Valid([]byte(line)) {
+			return FormatJSON
+		}
+	}
+	return format
+}
+
+// Next is a function used to move on to the next lender in the market.
+// Returns true if there is a lender to read with the Lender function, or
+// false at the end of the market or when the stream stops on an error.
+func (s *Stream) Next() bool {
+	for !s.done {
+		l, rowErrs, err := s.next()
+		switch {
+		case err == io.EOF:
+			s.done = true
+		case err != nil:
+			s.err, s.done = err, true
+		case len(rowErrs) > 0:
+			if s.errs.add(rowErrs...) {
+				s.done = true
+			}
+		default:
+			s.lender = l
+			return true
+		}
+	}
+	return false
+}
+
+// Lender is a function used to get the lender found by the last call to Next.
+// Returns the Lender.
+func (s *Stream) Lender() Lender {
+	return s.lender
+}
+
+// Err is a function used to get the error that stopped the stream, or the
+// bad rows that were skipped, once Next has returned false.
+// Returns the first FieldParseError in ImportStrict mode, an ImportErrors
+// listing the bad rows in the other modes, any error reading the market, or
+// nil if there were no errors.
+func (s *Stream) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	_, err := s.errs.result(nil)
+	return err
+}
+
+// Close is a function used to close the file opened by OpenStream. Streams
+// created with NewStream have nothing to close.
+// Returns any error closing the file.
+func (s *Stream) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// collect is a private function that reads every lender from the stream into
+// a slice, for the functions that import a whole market.
+// Returns the lenders and the error for the ImportMode.
+func (s *Stream) collect() (Lenders, error) {
+	var lenders Lenders
+	for s.Next() {
+		lenders = append(lenders, s.lender)
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.errs.result(lenders)
+}
@@ -0,0 +1,141 @@
+package lender
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readStream reads every lender from a stream.
+// Returns the lenders and the error from the stream.
+func readStream(s *Stream) (Lenders, error) {
+	var lenders Lenders
+	for s.Next() {
+		lenders = append(lenders, s.Lender())
+	}
+	return lenders, s.Err()
+}
+
+func TestOpenStreamReadsEveryFormat(t *testing.T) {
+	for _, filename := range []string{
+		"test_good_test.csv",
+		"test_market.tsv",
+		"test_market.json",
+		"test_market_wrapped.json",
+		"test_market.jsonl",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			s, err := OpenStream(filename, ImportOptions{})
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer s.Close()
+
+			lenders, err := readStream(s)
+			assertGoodMarket(t, lenders, err)
+		})
+	}
+}
+
+func TestNewStreamDetectsFormatFromContent(t *testing.T) {
+	for _, filename := range []string{
+		"test_good_test.csv",
+		"test_market.json",
+		"test_market_wrapped.json",
+		"test_market.jsonl",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			file, err := os.Open(filename)
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer file.Close()
+
+			s, err := NewStream(file, ImportOptions{})
+			assert.Nil(t, err)
+
+			lenders, err := readStream(s)
+			assertGoodMarket(t, lenders, err)
+		})
+	}
+}
+
+func TestOpenStreamFailsOnYAML(t *testing.T) {
+	_, err := OpenStream("test_market.yaml", ImportOptions{})
+
+	assert.True(t, errors.Is(err, ErrStreamFormat), "Expected YAML not to be streamed")
+}
+
+func TestOpenStreamFailsOnBadHeader(t *testing.T) {
+	_, err := OpenStream("test_missing_column.csv", ImportOptions{})
+
+	assert.True(t, errors.Is(err, ErrMissingColumn), "Expected the header to be checked when the stream is opened")
+}
+
+func TestStreamStopsAtBadRow(t *testing.T) {
+	s, err := NewStream(strings.NewReader("Lender,Rate,Available\nBob,0.075,640\nJane,bad_rate,480\nFred,0.071,520\n"), ImportOptions{})
+	assert.Nil(t, err)
+
+	lenders, err := readStream(s)
+
+	assert.Equal(t, 1, len(lenders), "Expected the lenders before the bad row")
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, 3, parseErr.LineNo)
+}
+
+func TestStreamLenientSkipsBadRows(t *testing.T) {
+	s, err := NewStream(strings.NewReader(`{"name": "Bob", "rate": 0.075, "available": 640}
+{"name": "Jane", "rate": "bad_rate", "available": 480}
+{"name": "Fred", "rate": 0.071, "available": 520}
+`), ImportOptions{Mode: ImportLenient})
+	assert.Nil(t, err)
+
+	lenders, err := readStream(s)
+
+	assert.Equal(t, 2, len(lenders))
+	var errs ImportErrors
+	assert.True(t, errors.As(err, &errs), "Expected the skipped line to be reported")
+	assert.Equal(t, []int{2}, errs.Lines())
+}
+
+func TestReadJSONChecksTheWholeMarket(t *testing.T) {
+	lenders, err := ReadJSON(strings.NewReader(`{"currency": "GBP", "lenders": [], "available": 0}`), ImportOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(lenders))
+
+	lenders, err = ReadJSON(strings.NewReader(`{"currency": "GBP", "lenders": null}`), ImportOptions{})
+	assert.Nil(t, err, "Expected null lenders to be an empty market")
+	assert.Equal(t, 0, len(lenders))
+
+	for _, market := range []string{`{"currency": "GBP"}`, `{"lendrs": [{"name": "Bob", "rate": 0.075, "available": 640}]}`} {
+		_, err := ReadJSON(strings.NewReader(market), ImportOptions{})
+		assert.True(t, errors.Is(err, ErrNoLenders), "Expected %s to have no lenders field", market)
+	}
+
+	for _, market := range []string{
+		``,
+		`"lenders"`,
+		`[{"name": "Bob", "rate": 0.075, "available": 640}`,
+		`[{"name": "Bob", "rate": 0.075, "available": 640}] []`,
+		`{"lenders": [{"name": "Bob", "rate": 0.075, "available": 640}], "currency": }`,
+	} {
+		_, err := ReadJSON(strings.NewReader(market), ImportOptions{})
+		assert.NotNil(t, err, "Expected an error for %q", market)
+	}
+}
+
+func TestFingerprintMatchesHash(t *testing.T) {
+	lenders, err := Import("test_market.json", ImportOptions{})
+	assert.Nil(t, err)
+
+	f := NewFingerprint()
+	for _, l := range lenders {
+		f.Add(l)
+	}
+
+	assert.Equal(t, lenders.Hash(), f.Sum())
+}
@@ -0,0 +1,254 @@
+package lender
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rules are the checks made on the lenders of a market by Validate, so that a
+// mistyped market, such as one with a rate of 7.5 instead of 0.075, is caught
+// before it is quoted from. Rates are fractions, such as 0.075 for 7.5%.
+type Rules struct {
+	// MinRate is the lowest rate a lender can offer.
+	MinRate float64 `json:"min_rate" yaml:"min_rate"`
+	// MaxRate is the highest rate a lender can offer. Zero means there is no
+	// limit.
+	MaxRate float64 `json:"max_rate" yaml:"max_rate"`
+	// WarnRate is the rate above which a lender is reported as a warning, as
+	// the rate is allowed but unusual. Zero means no warning is given.
+	WarnRate float64 `json:"warn_rate" yaml:"warn_rate"`
+	// DetectPercentages reports a rate above 1 that would be allowed once
+	// divided by 100 as a percentage entered by mistake.
+	DetectPercentages bool `json:"detect_percentages" yaml:"detect_percentages"`
+	// MinAvailable is the least a lender can have available.
+	MinAvailable int `json:"min_available" yaml:"min_available"`
+	// RequireNames reports lenders without a name.
+	RequireNames bool `json:"require_names" yaml:"require_names"`
+	// UniqueNames reports every lender after the first with the same name.
+	UniqueNames bool `json:"unique_names" yaml:"unique_names"`
+}
+
+// DefaultRules returns the rules used when a market is imported by goquote
+// and no others are given.
+func DefaultRules() Rules {
+	return Rules{
+		MinRate:           0,
+		MaxRate:           1,
+		WarnRate:          0.3,
+		DetectPercentages: true,
+		MinAvailable:      1,
+		RequireNames:      true,
+		UniqueNames:       true,
+	}
+}
+
+// Severity is how serious a Finding is.
+type Severity string
+
+const (
+	// SeverityError means the lender must not be quoted from.
+	SeverityError Severity = "error"
+	// SeverityWarning means the lender looks unusual and should be checked,
+	// but can be quoted from.
+	SeverityWarning Severity = "warning"
+)
+
+// Rule names the check that produced a Finding.
+type Rule string
+
+const (
+	// RuleName is used for a lender without a name.
+	RuleName Rule = "name"
+	// RuleUniqueName is used for a lender with the same name as an earlier one.
+	RuleUniqueName Rule = "unique_name"
+	// RuleRate is used for a rate outside of MinRate and MaxRate.
+	RuleRate Rule = "rate"
+	// RulePercentage is used for a rate that looks like a percentage.
+	RulePercentage Rule = "percentage"
+	// RuleHighRate is used for a rate above WarnRate.
+	RuleHighRate Rule = "high_rate"
+	// RuleAvailable is used for a lender with less than MinAvailable.
+	RuleAvailable Rule = "available"
+	// RuleTerms is used for term limits that are negative or the wrong way
+	// round.
+	RuleTerms Rule = "terms"
+)
+
+// Finding is a structure describing a problem with a single lender found by
+// Validate.
+type Finding struct {
+	// Index is the position of the lender in the slice that was validated,
+	// starting from zero.
+	Index int `json:"index" yaml:"index"`
+	// Lender is the name of the lender.
+	Lender string `json:"lender" yaml:"lender"`
+	// Field is the field of the lender with the problem.
+	Field string `json:"field" yaml:"field"`
+	// Value is the value of the field.
+	Value string `json:"value" yaml:"value"`
+	// Rule is the check that found the problem.
+	Rule Rule `json:"rule" yaml:"rule"`
+	// Severity is how serious the problem is.
+	Severity Severity `json:"severity" yaml:"severity"`
+	// Message describes the problem.
+	Message string `json:"message" yaml:"message"`
+}
+
+// String is a function used to describe the finding on a single line.
+// Returns a string such as `lender 2 (Bob), rate 7.5: The rate ...`.
+func (f Finding) String() string {
+	return fmt.Sprintf("lender %d (%s), %s %s: %s", f.Index+1, f.Lender, f.Field, f.Value, f.Message)
+}
+
+// Findings represents the problems found with a market by Validate, in the
+// order of the lenders.
+type Findings []Finding
+
+// Errors is a function used to get the findings that stop a lender being
+// quoted from.
+// Returns the findings with SeverityError.
+func (f Findings) Errors() Findings {
+	return f.with(SeverityError)
+}
+
+// Warnings is a function used to get the findings that only need checking.
+// Returns the findings with SeverityWarning.
+func (f Findings) Warnings() Findings {
+	return f.with(SeverityWarning)
+}
+
+// with is a private function that filters the findings by severity.
+// Returns the findings with the severity, in the same order.
+func (f Findings) with(severity Severity) Findings {
+	var found Findings
+	for _, finding := range f {
+		if finding.Severity == severity {
+			found = append(found, finding)
+		}
+	}
+	return found
+}
+
+// Err is a function used to turn the findings into an error if any of them
+// stop a lender being quoted from.
+// Returns a ValidationError holding the errors, or nil if there are only
+// warnings.
+func (f Findings) Err() error {
+	errs := f.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Findings: errs}
+}
+
+// ValidationError is an error structure that is used when lenders break the
+// Rules of a market.
+type ValidationError struct {
+	// Findings are the problems with SeverityError.
+	Findings Findings
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns every finding, one per line.
+func (e *ValidationError) Error() string {
+	noun := "problems"
+	if len(e.Findings) == 1 {
+		noun = "problem"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d %s with the lenders in the market:", len(e.Findings), noun)
+	for _, f := range e.Findings {
+		b.WriteString("\n  ")
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// Validate is a function used to check every lender against the rules, so
+// that a market that was imported successfully but holds nonsense values is
+// not quoted from.
+// Returns every problem found, or nil if there are none.
+func (slice Lenders) Validate(rules Rules) Findings {
+	var (
+		findings Findings
+		seen     = map[string]int{}
+	)
+
+	for i, l := range slice {
+		add := func(field, value string, rule Rule, severity Severity, format string, args ...interface{}) {
+			findings = append(findings, Finding{
+				Index:    i,
+				Lender:   l.Name,
+				Field:    field,
+				Value:    value,
+				Rule:     rule,
+				Severity: severity,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if rules.RequireNames && strings.TrimSpace(l.Name) == "" {
+			add("name", strconv.Quote(l.Name), RuleName, SeverityError, "The lender does not have a name")
+		}
+		if first, ok := seen[l.Name]; ok && rules.UniqueNames && l.Name != "" {
+			add("name", strconv.Quote(l.Name), RuleUniqueName, SeverityError, "The name is already used by lender %d", first+1)
+		} else if !ok {
+			seen[l.Name] = i
+		}
+
+		rate := strconv.FormatFloat(l.Rate, 'g', -1, 64)
+		switch {
+		case l.Rate < rules.MinRate:
+			add("rate", rate, RuleRate, SeverityError, "The rate is below the minimum of %g", rules.MinRate)
+		case rules.DetectPercentages && l.Rate > 1 && rules.allowsRate(l.Rate/100):
+			add("rate", rate, RulePercentage, SeverityError, "The rate looks like a percentage, did you mean %g?", l.Rate/100)
+		case rules.MaxRate > 0 && l.Rate > rules.MaxRate:
+			add("rate", rate, RuleRate, SeverityError, "The rate is above the maximum of %g", rules.MaxRate)
+		case rules.WarnRate > 0 && l.Rate > rules.WarnRate:
+			add("rate", rate, RuleHighRate, SeverityWarning, "The rate is unusually high")
+		}
+
+		if l.Available < rules.MinAvailable {
+			add("available", strconv.Itoa(l.Available), RuleAvailable, SeverityError, "The amount available is below the minimum of %d", rules.MinAvailable)
+		}
+
+		switch {
+		case l.MinTerm < 0:
+			add("min_term", strconv.Itoa(l.MinTerm), RuleTerms, SeverityError, "The term limit cannot be negative")
+		case l.MaxTerm < 0:
+			add("max_term", strconv.Itoa(l.MaxTerm), RuleTerms, SeverityError, "The term limit cannot be negative")
+		case l.MaxTerm > 0 && l.MinTerm > l.MaxTerm:
+			add("min_term", strconv.Itoa(l.MinTerm), RuleTerms, SeverityError, "The minimum term is longer than the maximum term of %d", l.MaxTerm)
+		}
+	}
+
+	return findings
+}
+
+// allowsRate is a private function that checks a rate is within the MinRate
+// and MaxRate.
+// Returns true if the rate is allowed.
+func (r Rules) allowsRate(rate float64) bool {
+	return rate >= r.MinRate && (r.MaxRate == 0 || rate <= r.MaxRate)
+}
+
+// Without is a function used to drop the lenders with an error in the
+// findings, which must have come from calling Validate on the same slice. The
+// slice it is called on is left unchanged.
+// Returns the remaining lenders, in the same order.
+func (slice Lenders) Without(findings Findings) Lenders {
+	bad := map[int]bool{}
+	for _, f := range findings.Errors() {
+		bad[f.Index] = true
+	}
+
+	var lenders Lenders
+	for i, l := range slice {
+		if !bad[i] {
+			lenders = append(lenders, l)
+		}
+	}
+	return lenders
+}
@@ -0,0 +1,103 @@
+package lender
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAcceptsGoodMarket(t *testing.T) {
+	lenders, err := ImportCSV("test_good_test.csv")
+	assert.Nil(t, err)
+
+	findings := lenders.Validate(DefaultRules())
+
+	assert.Nil(t, findings, "Expected no problems with a good market")
+	assert.Nil(t, findings.Err())
+}
+
+func TestValidateFindsMistypedMarket(t *testing.T) {
+	lenders := Lenders{
+		{Name: "Bob", Rate: 0.075, Available: 640},
+		{Name: "", Rate: 0.069, Available: 480},
+		{Name: "Jane", Rate: 7.5, Available: 0},
+		{Name: "Bob", Rate: -0.01, Available: 100},
+		{Name: "Fred", Rate: 150, Available: 100},
+		{Name: "Anna", Rate: 0.45, Available: 100, MinTerm: 36, MaxTerm: 12},
+	}
+
+	findings := lenders.Validate(DefaultRules())
+
+	type check struct {
+		index    int
+		field    string
+		rule     Rule
+		severity Severity
+	}
+	var checks []check
+	for _, f := range findings {
+		checks = append(checks, check{f.Index, f.Field, f.Rule, f.Severity})
+	}
+	assert.Equal(t, []check{
+		{1, "name", RuleName, SeverityError},
+		{2, "rate", RulePercentage, SeverityError},
+		{2, "available", RuleAvailable, SeverityError},
+		{3, "name", RuleUniqueName, SeverityError},
+		{3, "rate", RuleRate, SeverityError},
+		{4, "rate", RuleRate, SeverityError},
+		{5, "rate", RuleHighRate, SeverityWarning},
+		{5, "min_term", RuleTerms, SeverityError},
+	}, checks)
+
+	assert.Equal(t, "lender 3 (Jane), rate 7.5: The rate looks like a percentage, did you mean 0.075?", findings[1].String())
+	assert.Equal(t, "lender 4 (Bob), name \"Bob\": The name is already used by lender 1", findings[3].String())
+	assert.Equal(t, 1, len(findings.Warnings()))
+	assert.Equal(t, 7, len(findings.Errors()))
+}
+
+func TestValidateUsesRules(t *testing.T) {
+	lenders := Lenders{
+		{Name: "Bob", Rate: 0.075, Available: 640},
+		{Name: "Bob", Rate: 7.5, Available: 10},
+	}
+
+	findings := lenders.Validate(Rules{MaxRate: 10})
+	assert.Nil(t, findings, "Expected only the rules given to be checked")
+
+	findings = lenders.Validate(Rules{MaxRate: 0.1, MinAvailable: 50})
+	assert.Equal(t, 2, len(findings))
+	assert.Equal(t, RuleRate, findings[0].Rule, "Expected no percentage detection")
+	assert.Equal(t, RuleAvailable, findings[1].Rule)
+}
+
+func TestFindingsErr(t *testing.T) {
+	lenders := Lenders{
+		{Name: "Bob", Rate: 0.45, Available: 640},
+		{Name: "Jane", Rate: 0.069, Available: -10},
+	}
+
+	findings := lenders.Validate(DefaultRules())
+	err := findings.Err()
+
+	var invalid *ValidationError
+	assert.True(t, errors.As(err, &invalid), "Expected a ValidationError")
+	assert.Equal(t, 1, len(invalid.Findings), "Expected warnings to be left out")
+	assert.Equal(t, "Found 1 problem with the lenders in the market:\n"+
+		"  lender 2 (Jane), available -10: The amount available is below the minimum of 1", err.Error())
+
+	assert.Nil(t, findings.Warnings().Err(), "Expected warnings not to be an error")
+}
+
+func TestLendersWithout(t *testing.T) {
+	lenders := Lenders{
+		{Name: "Bob", Rate: 0.45, Available: 640},
+		{Name: "Jane", Rate: 0.069, Available: 0},
+		{Name: "Fred", Rate: 0.071, Available: 520},
+	}
+
+	valid := lenders.Without(lenders.Validate(DefaultRules()))
+
+	assert.Equal(t, Lenders{lenders[0], lenders[2]}, valid, "Expected lenders with only warnings to be kept")
+	assert.Equal(t, 3, len(lenders), "Expected the original slice to be unchanged")
+}
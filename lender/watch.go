@@ -0,0 +1,133 @@
+package lender
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrEmptyMarket is returned when a reloaded market file has no lenders in
+// it.
+var ErrEmptyMarket = errors.New("The market does not contain any lenders")
+
+// Watcher is a structure used to reload a market file when it changes on disk,
+// so that a long running server always quotes from the latest market. A new
+// market is only handed over once it has been imported and checked in full,
+// so a half written or broken file never replaces a good market.
+type Watcher struct {
+	filename string
+	opts     ImportOptions
+	rules    Rules
+	modTime  time.Time
+	size     int64
+	hash     string
+}
+
+// NewWatcher is a function used to create a watcher for the market file
+// located by filename, which is imported using the options and checked
+// against the rules. The lenders currently in use, usually imported from the
+// same file, are used to tell whether later changes are worth reloading.
+// Returns a pointer to the Watcher if the file exists, or the associated error
+// otherwise.
+func NewWatcher(filename string, current Lenders, opts ImportOptions, rules Rules) (*Watcher, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		filename: filename,
+		opts:     opts,
+		rules:    rules,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		hash:     current.Hash(),
+	}, nil
+}
+
+// Check is a function used to look for a change to the market file and
+// reload it if there is one. The file is imported with Import, and must
+// contain at least one lender with every lender in the same currency and no
+// errors found by Validate. A file that fails to reload is not tried again
+// until it changes.
+// Returns the new lenders if the market has changed, nil if it has not, or
+// the associated error, such as a FieldParseError or ValidationError, if the
+// file has changed but cannot be used. The previous market should be kept on
+// error. In ImportLenient mode, a market with bad rows or invalid lenders is
+// returned without them along with the ImportErrors and ValidationError for
+// the lenders that were skipped.
+func (w *Watcher) Check() (Lenders, error) {
+	info, err := os.Stat(w.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return nil, nil
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+
+	lenders, skipped := Import(w.filename, w.opts)
+	if skipped != nil && len(lenders) == 0 {
+		return nil, skipped
+	}
+
+	if len(lenders) == 0 {
+		return nil, ErrEmptyMarket
+	}
+
+	if _, err := lenders.Currency(); err != nil {
+		return nil, err
+	}
+
+	findings := lenders.Validate(w.rules)
+	if invalid := findings.Err(); invalid != nil {
+		if w.opts.Mode != ImportLenient {
+			return nil, invalid
+		}
+
+		lenders = lenders.Without(findings)
+		if len(lenders) == 0 {
+			return nil, invalid
+		}
+		skipped = errors.Join(skipped, invalid)
+	}
+
+	// Touching the file without changing the lenders is not a new market.
+	hash := lenders.Hash()
+	if hash == w.hash {
+		return nil, skipped
+	}
+	w.hash = hash
+
+	return lenders, skipped
+}
+
+// Watch is a function used to check the market file every interval until the
+// context is cancelled. Each new market is passed to reload, which should
+// swap it in atomically, such as with the SetMarket function of the server.
+// Errors are passed to fail, and the previous market stays in use unless
+// rows were skipped in ImportLenient mode, in which case the new market is
+// passed to reload before the skipped rows are passed to fail. The reloaded
+// argument of fail tells the two apart, as it is true only when reload was
+// called with the rest of the market in the same check.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration, reload func(Lenders), fail func(err error, reloaded bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lenders, err := w.Check()
+			if lenders != nil {
+				reload(lenders)
+			}
+			if err != nil {
+				fail(err, lenders != nil)
+			}
+		}
+	}
+}
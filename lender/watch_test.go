@@ -0,0 +1,217 @@
+package lender
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeMarket writes a market file and moves its modification time forward,
+// so the change is seen even on file systems with coarse timestamps.
+func writeMarket(t *testing.T, filename, data string, modTime time.Time) {
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestWatcher creates a watcher for a market file with two lenders.
+// Returns the Watcher and the name of the file it watches.
+func newTestWatcher(t *testing.T) (*Watcher, string) {
+	filename := filepath.Join(t.TempDir(), "market.csv")
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\nJane,0.069,480\n", time.Unix(1000, 0))
+
+	lenders, err := ImportCSV(filename)
+	assert.Nil(t, err)
+
+	w, err := NewWatcher(filename, lenders, ImportOptions{}, DefaultRules())
+	assert.Nil(t, err)
+
+	return w, filename
+}
+
+func TestNewWatcherFailsOnNotFound(t *testing.T) {
+	_, err := NewWatcher("unknownfile.csv", nil, ImportOptions{}, DefaultRules())
+
+	assert.NotNil(t, err, "Expected an error as the csv file cannot be found")
+}
+
+func TestWatcherCheckUnchanged(t *testing.T) {
+	w, _ := newTestWatcher(t)
+
+	lenders, err := w.Check()
+
+	assert.Nil(t, err)
+	assert.Nil(t, lenders, "Expected no reload when the file has not changed")
+}
+
+func TestWatcherCheckReloadsChangedFile(t *testing.T) {
+	w, filename := newTestWatcher(t)
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\nJane,0.069,480\nFred,0.071,520\n", time.Unix(2000, 0))
+	lenders, err := w.Check()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(lenders), "Expected the new market")
+
+	lenders, err = w.Check()
+	assert.Nil(t, err)
+	assert.Nil(t, lenders, "Expected the change to be reloaded once")
+}
+
+func TestWatcherCheckIgnoresTouchedFile(t *testing.T) {
+	w, filename := newTestWatcher(t)
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\nJane,0.069,480\n", time.Unix(2000, 0))
+	lenders, err := w.Check()
+
+	assert.Nil(t, err)
+	assert.Nil(t, lenders, "Expected no reload when the lenders are the same")
+}
+
+func TestWatcherCheckReportsBadFile(t *testing.T) {
+	w, filename := newTestWatcher(t)
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,bad_rate,640\n", time.Unix(2000, 0))
+	lenders, err := w.Check()
+
+	var parseErr *FieldParseError
+	assert.True(t, errors.As(err, &parseErr), "Expected a FieldParseError")
+	assert.Equal(t, 2, parseErr.LineNo)
+	assert.Nil(t, lenders)
+
+	lenders, err = w.Check()
+	assert.Nil(t, err, "Expected a broken file to be reported once")
+	assert.Nil(t, lenders)
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,700\n", time.Unix(3000, 0))
+	lenders, err = w.Check()
+	assert.Nil(t, err, "Expected the fixed file to be reloaded")
+	assert.Equal(t, 1, len(lenders))
+}
+
+func TestWatcherCheckRejectsInvalidMarkets(t *testing.T) {
+	w, filename := newTestWatcher(t)
+
+	writeMarket(t, filename, "Lender,Rate,Available\n", time.Unix(2000, 0))
+	_, err := w.Check()
+	assert.True(t, errors.Is(err, ErrEmptyMarket), "Expected an empty market to be rejected")
+
+	writeMarket(t, filename, "Lender,Rate,Available,Currency\nBob,0.075,640,GBP\nAnna,0.069,480,EUR\n", time.Unix(3000, 0))
+	_, err = w.Check()
+	assert.True(t, errors.Is(err, ErrMixedCurrencies), "Expected mixed currencies to be rejected")
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,7.5,640\n", time.Unix(4000, 0))
+	_, err = w.Check()
+	var invalid *ValidationError
+	assert.True(t, errors.As(err, &invalid), "Expected a rate entered as a percentage to be rejected")
+}
+
+func TestWatcherCheckLenientSkipsBadRows(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "market.csv")
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\n", time.Unix(1000, 0))
+
+	w, err := NewWatcher(filename, nil, ImportOptions{Mode: ImportLenient}, DefaultRules())
+	assert.Nil(t, err)
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\nJane,bad_rate,480\n", time.Unix(2000, 0))
+	lenders, err := w.Check()
+
+	var errs ImportErrors
+	assert.True(t, errors.As(err, &errs), "Expected the skipped row to be reported")
+	assert.Equal(t, 1, len(lenders), "Expected the good rows to be reloaded")
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\nJane,0.069,0\nFred,0.071,520\n", time.Unix(3000, 0))
+	lenders, err = w.Check()
+
+	var invalid *ValidationError
+	assert.True(t, errors.As(err, &invalid), "Expected the invalid lender to be reported")
+	assert.Equal(t, Lenders{{Name: "Bob", Rate: 0.075, Available: 640}, {Name: "Fred", Rate: 0.071, Available: 520}}, lenders)
+}
+
+func TestWatcherWatch(t *testing.T) {
+	w, filename := newTestWatcher(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The file can be seen half written, so never block the watcher.
+	reloaded := make(chan Lenders, 10)
+	failed := make(chan error, 10)
+	go w.Watch(ctx, time.Millisecond,
+		func(lenders Lenders) {
+			select {
+			case reloaded <- lenders:
+			default:
+			}
+		},
+		func(err error, reloaded bool) {
+			assert.False(t, reloaded, "Expected nothing to be reloaded from a broken file")
+			select {
+			case failed <- err:
+			default:
+			}
+		})
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,bad_rate,640\n", time.Unix(2000, 0))
+	select {
+	case err := <-failed:
+		assert.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the broken file to be reported")
+	}
+
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,700\n", time.Unix(3000, 0))
+	select {
+	case lenders := <-reloaded:
+		assert.Equal(t, 700, lenders[0].Available)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the fixed file to be reloaded")
+	}
+}
+
+func TestWatcherWatchLenientReportsWhetherRowsWereSkipped(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "market.csv")
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,640\n", time.Unix(1000, 0))
+
+	w, err := NewWatcher(filename, nil, ImportOptions{Mode: ImportLenient}, DefaultRules())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failed := make(chan bool, 10)
+	go w.Watch(ctx, time.Millisecond,
+		func(lenders Lenders) {},
+		func(err error, reloaded bool) {
+			select {
+			case failed <- reloaded:
+			default:
+			}
+		})
+
+	// Every row is bad, so nothing is reloaded.
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,bad_rate,640\n", time.Unix(2000, 0))
+	select {
+	case reloaded := <-failed:
+		assert.False(t, reloaded, "Expected the previous market to be kept")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the broken file to be reported")
+	}
+
+	// Only some rows are bad, so the rest of the market is reloaded.
+	writeMarket(t, filename, "Lender,Rate,Available\nBob,0.075,700\nJane,bad_rate,480\n", time.Unix(3000, 0))
+	select {
+	case reloaded := <-failed:
+		assert.True(t, reloaded, "Expected the good rows to be reloaded")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the skipped row to be reported")
+	}
+}
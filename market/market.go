@@ -0,0 +1,285 @@
+// Package market keeps track of the funds in a pool of lenders as quotes are
+// issued, accepted and declined.
+package market
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/quote"
+)
+
+var (
+	// ErrUnknownReservation is used when a reservation cannot be found, either
+	// because it never existed or because it has already been committed,
+	// released or cleared after expiring.
+	ErrUnknownReservation = errors.New("The reservation could not be found")
+	// ErrReservationExpired is used when a reservation is committed after it
+	// has expired.
+	ErrReservationExpired = errors.New("The reservation has expired")
+	// ErrInsufficientLiquidity is used when a lender no longer has enough
+	// funds that are not reserved to cover an allocation.
+	ErrInsufficientLiquidity = errors.New("The market no longer has the funds for this quote")
+	// ErrInvalidTTL is used when funds are reserved for a time that is not
+	// positive, as the reservation would already have expired.
+	ErrInvalidTTL = errors.New("The time to reserve funds for must be positive")
+)
+
+// Reservation represents funds held back for a quote that has been issued
+// but not yet accepted.
+type Reservation struct {
+	// ID identifies the reservation when it is committed or released.
+	ID string `json:"id" yaml:"id"`
+	// Allocations are the amounts held back from each lender.
+	Allocations quote.Allocations `json:"allocations" yaml:"allocations"`
+	// ExpiresAt is when the funds stop being held back.
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+}
+
+// Liquidity represents how much of a lender's funds can still be lent.
+type Liquidity struct {
+	// Lender is the name of the lender.
+	Lender string `json:"lender" yaml:"lender"`
+	// Available is the lender's funds that have not been committed to a loan.
+	Available int `json:"available" yaml:"available"`
+	// Reserved is the part of Available held back for issued quotes.
+	Reserved int `json:"reserved" yaml:"reserved"`
+	// Free is the part of Available that can be used for new quotes.
+	Free int `json:"free" yaml:"free"`
+}
+
+// Market represents a pool of lenders whose funds are reserved when a quote is
+// issued, committed when the borrower accepts and released if they decline or
+// the quote expires. A Market is safe for concurrent use.
+type Market struct {
+	mu           sync.Mutex
+	lenders      lender.Lenders
+	index        map[string]int
+	reservations map[string]*Reservation
+	now          func() time.Time
+}
+
+// New is a function used to create a market from a pool of lenders, such as
+// one loaded with lender.ImportCSV. Lenders are identified by name, so every
+// name must be unique.
+// Returns a pointer to the Market if successful, or the associated error
+// otherwise.
+func New(lenders lender.Lenders) (*Market, error) {
+	m := &Market{
+		lenders:      make(lender.Lenders, len(lenders)),
+		index:        make(map[string]int, len(lenders)),
+		reservations: make(map[string]*Reservation),
+		now:          time.Now,
+	}
+
+	copy(m.lenders, lenders)
+	for i, l := range m.lenders {
+		if _, ok := m.index[l.Name]; ok {
+			return nil, fmt.Errorf("The lender %s appears more than once in the market", l.Name)
+		}
+		m.index[l.Name] = i
+	}
+
+	return m, nil
+}
+
+// Lenders is a function used to get the lenders with the funds that can be
+// used for new quotes, which excludes any funds already committed or
+// reserved.
+// Returns a copy of the lenders that can safely be changed by the caller.
+func (m *Market) Lenders() lender.Lenders {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.free()
+}
+
+// Liquidity is a function used to report how much each lender has available,
+// reserved and free to lend, in the order the lenders were added.
+// Returns a Liquidity for every lender.
+func (m *Market) Liquidity() []Liquidity {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expire()
+	reserved := m.reserved()
+
+	liquidity := make([]Liquidity, len(m.lenders))
+	for i, l := range m.lenders {
+		liquidity[i] = Liquidity{
+			Lender:    l.Name,
+			Available: l.Available,
+			Reserved:  reserved[i],
+			Free:      l.Available - reserved[i],
+		}
+	}
+
+	return liquidity
+}
+
+// Quote is a function used to calculate a quote against the funds that are
+// free in the market and reserve them until the quote expires after the ttl.
+// The lenders in the request are replaced by the market's, and the
+// reservation has the same ID as the quote. Calculating and reserving happen
+// together, so concurrent quotes can never reserve the same funds. The ttl
+// must be positive, as it replaces the ValidFor option of the request.
+// Returns the Quote and its Reservation if successful, ErrInvalidTTL if the
+// ttl is not positive, or the associated error otherwise.
+func (m *Market) Quote(req quote.QuoteRequest, ttl time.Duration) (*quote.Quote, *Reservation, error) {
+	if ttl <= 0 {
+		return nil, nil, ErrInvalidTTL
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req.Lenders = m.free()
+	req.Options.CreatedAt = m.now()
+	req.Options.ValidFor = ttl
+
+	q, err := quote.Calculate(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := m.reserve(q.ID, q.Allocations, q.ExpiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return q, r, nil
+}
+
+// Reserve is a function used to hold back funds for allocations that were
+// calculated separately, such as from the Lenders of the market, until the
+// ttl has passed.
+// Returns the Reservation if successful, ErrInvalidTTL if the ttl is not
+// positive, or ErrInsufficientLiquidity if the funds are no longer free.
+func (m *Market) Reserve(allocations quote.Allocations, ttl time.Duration) (*Reservation, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, err := quote.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.reserve(id, allocations, m.now().Add(ttl))
+}
+
+// Commit is a function used when the borrower accepts a quote. The reserved
+// funds are taken from the lenders for good.
+// Returns ErrUnknownReservation or ErrReservationExpired if the reservation
+// cannot be committed, or nil otherwise.
+func (m *Market) Commit(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.reservations[id]
+	if !ok {
+		return ErrUnknownReservation
+	}
+
+	delete(m.reservations, id)
+	if !m.now().Before(r.ExpiresAt) {
+		return ErrReservationExpired
+	}
+
+	for _, a := range r.Allocations {
+		m.lenders[m.index[a.Lender]].Available -= a.Amount
+	}
+
+	return nil
+}
+
+// Release is a function used when the borrower declines a quote, so that the
+// reserved funds can be used for other quotes.
+// Returns ErrUnknownReservation if the reservation cannot be found, or nil
+// otherwise.
+func (m *Market) Release(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.reservations[id]; !ok {
+		return ErrUnknownReservation
+	}
+
+	delete(m.reservations, id)
+	return nil
+}
+
+// reserve is a private function that holds back funds for the allocations
+// until they expire. The caller must hold the lock.
+// Returns the Reservation if successful, or ErrInsufficientLiquidity if the
+// funds are no longer free.
+func (m *Market) reserve(id string, allocations quote.Allocations, expires time.Time) (*Reservation, error) {
+	m.expire()
+	reserved := m.reserved()
+
+	// Check every allocation can still be funded before reserving any of them.
+	for _, a := range allocations {
+		i, ok := m.index[a.Lender]
+		if !ok || a.Amount > m.lenders[i].Available-reserved[i] {
+			return nil, ErrInsufficientLiquidity
+		}
+		reserved[i] += a.Amount
+	}
+
+	r := &Reservation{
+		ID:          id,
+		Allocations: make(quote.Allocations, len(allocations)),
+		ExpiresAt:   expires,
+	}
+	copy(r.Allocations, allocations)
+	m.reservations[id] = r
+
+	// Hand back a copy so the caller cannot change the reservation.
+	reservation := *r
+	return &reservation, nil
+}
+
+// expire is a private function that releases every reservation that has
+// expired. The caller must hold the lock.
+func (m *Market) expire() {
+	now := m.now()
+	for id, r := range m.reservations {
+		if !now.Before(r.ExpiresAt) {
+			delete(m.reservations, id)
+		}
+	}
+}
+
+// reserved is a private function that adds up the reserved funds for each
+// lender. The caller must hold the lock.
+// Returns the reserved amount for each lender, by position.
+func (m *Market) reserved() []int {
+	reserved := make([]int, len(m.lenders))
+	for _, r := range m.reservations {
+		for _, a := range r.Allocations {
+			reserved[m.index[a.Lender]] += a.Amount
+		}
+	}
+	return reserved
+}
+
+// free is a private function that returns a copy of the lenders with only the
+// funds that are not reserved. The caller must hold the lock.
+func (m *Market) free() lender.Lenders {
+	m.expire()
+	reserved := m.reserved()
+
+	lenders := make(lender.Lenders, len(m.lenders))
+	copy(lenders, m.lenders)
+	for i := range lenders {
+		lenders[i].Available -= reserved[i]
+	}
+
+	return lenders
+}
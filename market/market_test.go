@@ -0,0 +1,187 @@
+package market
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/quote"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestMarket creates a market with a clock that can be moved on by the
+// tests.
+func newTestMarket(t *testing.T) (*Market, *time.Time) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 1000})
+
+	m, err := New(lenders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2015, time.January, 15, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestNewFailsOnDuplicateLenders(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.069, Available: 200})
+
+	_, err := New(lenders)
+
+	assert.NotNil(t, err, "Expected an error as Jane appears twice")
+}
+
+func TestQuoteReservesFunds(t *testing.T) {
+	assert := assert.New(t)
+	m, _ := newTestMarket(t)
+
+	q, r, err := m.Quote(quote.QuoteRequest{Amount: 1200, Term: 36}, time.Hour)
+
+	assert.Nil(err, "Expected the quote to be reserved")
+	assert.Equal(q.Allocations, r.Allocations)
+	assert.Equal(q.ID, r.ID, "Expected the reservation to share the quote ID")
+	assert.Equal(q.ExpiresAt, r.ExpiresAt, "Expected the reservation to expire with the quote")
+	assert.Equal([]Liquidity{
+		{Lender: "Jane", Available: 1000, Reserved: 1000, Free: 0},
+		{Lender: "Bob", Available: 1000, Reserved: 200, Free: 800},
+	}, m.Liquidity())
+
+	// The next quote can only use what is left.
+	q, _, err = m.Quote(quote.QuoteRequest{Amount: 800, Term: 36, Options: quote.Options{
+		Policy: &quote.Policy{MinAmount: 100, MaxAmount: 1000, Step: 100},
+	}}, time.Hour)
+	assert.Nil(err, "Expected the quote to be reserved")
+	assert.Equal("Bob", q.Allocations[0].Lender)
+
+	_, _, err = m.Quote(quote.QuoteRequest{Amount: 1000, Term: 36}, time.Hour)
+	assert.True(errors.Is(err, quote.ErrInsufficientFunds), "Expected the market to be fully reserved")
+}
+
+func TestCommitTakesFunds(t *testing.T) {
+	assert := assert.New(t)
+	m, _ := newTestMarket(t)
+
+	_, r, err := m.Quote(quote.QuoteRequest{Amount: 1200, Term: 36}, time.Hour)
+	assert.Nil(err, "Expected the quote to be reserved")
+
+	assert.Nil(m.Commit(r.ID), "Expected the reservation to be committed")
+	assert.Equal([]Liquidity{
+		{Lender: "Jane", Available: 0, Reserved: 0, Free: 0},
+		{Lender: "Bob", Available: 800, Reserved: 0, Free: 800},
+	}, m.Liquidity())
+
+	assert.Equal(ErrUnknownReservation, m.Commit(r.ID), "Expected a reservation to be committed once")
+}
+
+func TestReleaseFreesFunds(t *testing.T) {
+	assert := assert.New(t)
+	m, _ := newTestMarket(t)
+
+	_, r, err := m.Quote(quote.QuoteRequest{Amount: 1200, Term: 36}, time.Hour)
+	assert.Nil(err, "Expected the quote to be reserved")
+
+	assert.Nil(m.Release(r.ID), "Expected the reservation to be released")
+	assert.Equal(1000, m.Liquidity()[0].Free)
+	assert.Equal(ErrUnknownReservation, m.Release(r.ID))
+	assert.Equal(ErrUnknownReservation, m.Commit(r.ID))
+}
+
+func TestReservationsExpire(t *testing.T) {
+	assert := assert.New(t)
+	m, now := newTestMarket(t)
+
+	q, r, err := m.Quote(quote.QuoteRequest{Amount: 1200, Term: 36}, time.Hour)
+	assert.Nil(err, "Expected the quote to be reserved")
+	assert.Equal(*now, q.CreatedAt, "Expected the quote to use the market clock")
+	assert.Equal(now.Add(time.Hour), r.ExpiresAt)
+
+	*now = now.Add(time.Hour)
+
+	assert.Equal(ErrReservationExpired, m.Commit(r.ID), "Expected the reservation to have expired")
+	assert.Equal(1000, m.Liquidity()[0].Free, "Expected the funds to be free again")
+}
+
+func TestExpiredReservationsAreReleased(t *testing.T) {
+	m, now := newTestMarket(t)
+
+	_, r, err := m.Quote(quote.QuoteRequest{Amount: 1200, Term: 36}, time.Minute)
+	assert.Nil(t, err, "Expected the quote to be reserved")
+
+	*now = now.Add(time.Minute)
+
+	assert.Equal(t, 1000, m.Lenders()[0].Available, "Expected the funds to be free again")
+	assert.Equal(t, ErrUnknownReservation, m.Commit(r.ID))
+}
+
+func TestReserveFailsWithoutLiquidity(t *testing.T) {
+	m, _ := newTestMarket(t)
+
+	_, err := m.Reserve(quote.Allocations{{Lender: "Jane", Amount: 600}}, time.Hour)
+	assert.Nil(t, err, "Expected the funds to be reserved")
+
+	_, err = m.Reserve(quote.Allocations{
+		{Lender: "Bob", Amount: 100},
+		{Lender: "Jane", Amount: 600},
+	}, time.Hour)
+	assert.Equal(t, ErrInsufficientLiquidity, err, "Expected Jane to only have 400 free")
+	assert.Equal(t, 1000, m.Liquidity()[1].Free, "Expected nothing to be reserved from Bob")
+
+	_, err = m.Reserve(quote.Allocations{{Lender: "Sam", Amount: 1}}, time.Hour)
+	assert.Equal(t, ErrInsufficientLiquidity, err, "Expected an error as Sam is not in the market")
+}
+
+func TestReserveFailsWithoutPositiveTTL(t *testing.T) {
+	m, _ := newTestMarket(t)
+
+	for _, ttl := range []time.Duration{0, -time.Hour} {
+		_, _, err := m.Quote(quote.QuoteRequest{Amount: 1200, Term: 36}, ttl)
+		assert.Equal(t, ErrInvalidTTL, err, "Expected a ttl of %s to be rejected", ttl)
+
+		_, err = m.Reserve(quote.Allocations{{Lender: "Jane", Amount: 600}}, ttl)
+		assert.Equal(t, ErrInvalidTTL, err, "Expected a ttl of %s to be rejected", ttl)
+	}
+
+	assert.Equal(t, 0, m.Liquidity()[0].Reserved, "Expected nothing to be reserved")
+}
+
+func TestLendersReturnsCopy(t *testing.T) {
+	m, _ := newTestMarket(t)
+
+	lenders := m.Lenders()
+	lenders[0].Available = 0
+
+	assert.Equal(t, 1000, m.Lenders()[0].Available, "Expected the market to be unchanged")
+}
+
+func TestQuoteIsSafeForConcurrentUse(t *testing.T) {
+	m, _ := newTestMarket(t)
+
+	// Only one of the quotes can be funded at a time, so exactly one should
+	// be reserved.
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := m.Quote(quote.QuoteRequest{Amount: 1500, Term: 36}, time.Hour); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, reserved, "Expected only one quote to reserve the funds")
+}
@@ -0,0 +1,92 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is the code of the currency used when none is given.
+const DefaultCurrency = "GBP"
+
+// Currency represents the details needed to display amounts in a currency.
+type Currency struct {
+	// Code is the ISO 4217 code of the currency, such as GBP.
+	Code string
+	// Symbol is shown in front of amounts, such as £.
+	Symbol string
+	// MinorUnits is the number of decimal places shown for amounts.
+	MinorUnits int
+	// DecimalSeparator separates the major and minor units, such as ".".
+	DecimalSeparator string
+}
+
+// currencies holds every supported currency, keyed by code.
+var currencies = map[string]Currency{
+	"GBP": {Code: "GBP", Symbol: "£", MinorUnits: 2, DecimalSeparator: "."},
+	"EUR": {Code: "EUR", Symbol: "€", MinorUnits: 2, DecimalSeparator: ","},
+	"USD": {Code: "USD", Symbol: "$", MinorUnits: 2, DecimalSeparator: "."},
+}
+
+// LookupCurrency is a function used to find a supported currency by its code.
+// The code is not case sensitive, and an empty code returns the
+// DefaultCurrency.
+// Returns the Currency if it is supported, or an error otherwise.
+func LookupCurrency(code string) (Currency, error) {
+	if code == "" {
+		code = DefaultCurrency
+	}
+
+	c, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("Unsupported currency %q", code)
+	}
+	return c, nil
+}
+
+// CurrencyOrDefault is like LookupCurrency but falls back to the
+// DefaultCurrency when the code is not supported, instead of returning an
+// error. It should only be used when displaying amounts whose currency has
+// already been checked.
+// Returns the Currency.
+func CurrencyOrDefault(code string) Currency {
+	c, err := LookupCurrency(code)
+	if err != nil {
+		c, _ = LookupCurrency(DefaultCurrency)
+	}
+	return c
+}
+
+// Format is a function used to display an amount in the currency, such as
+// £1111.60.
+// Returns a string representing the amount.
+func (c Currency) Format(a Amount) string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+
+	// Amounts are held in hundredths, so round them to the minor units of the
+	// currency before display.
+	scale := Amount(1)
+	for i := c.MinorUnits; i < 2; i++ {
+		scale *= 10
+	}
+	a = Amount(HalfUp.Round(float64(a)/float64(scale))) * scale
+
+	s := fmt.Sprintf("%s%s%d", sign, c.Symbol, a/minorUnits)
+	if c.MinorUnits > 0 {
+		s += c.DecimalSeparator + fmt.Sprintf("%02d", a%minorUnits)[:c.MinorUnits]
+	}
+	return s
+}
+
+// FormatMajor is a function used to display a whole number of major units in
+// the currency, such as £1000.
+// Returns a string representing the amount.
+func (c Currency) FormatMajor(units int) string {
+	if units < 0 {
+		return fmt.Sprintf("-%s%d", c.Symbol, -units)
+	}
+	return fmt.Sprintf("%s%d", c.Symbol, units)
+}
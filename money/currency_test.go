@@ -0,0 +1,47 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	c, err := LookupCurrency("eur")
+	assert.Nil(t, err, "Expected EUR to be supported")
+	assert.Equal(t, "EUR", c.Code)
+	assert.Equal(t, "€", c.Symbol)
+
+	c, err = LookupCurrency("")
+	assert.Nil(t, err, "Expected an empty code to give the default")
+	assert.Equal(t, DefaultCurrency, c.Code)
+
+	_, err = LookupCurrency("XYZ")
+	assert.NotNil(t, err, "Expected an error as XYZ is not supported")
+}
+
+func TestCurrencyOrDefaultFallsBackToDefault(t *testing.T) {
+	assert.Equal(t, DefaultCurrency, CurrencyOrDefault("XYZ").Code)
+	assert.Equal(t, "USD", CurrencyOrDefault("USD").Code)
+}
+
+func TestCurrencyFormat(t *testing.T) {
+	assert.Equal(t, "£1111.60", CurrencyOrDefault("GBP").Format(111160))
+	assert.Equal(t, "$1111.60", CurrencyOrDefault("USD").Format(111160))
+	assert.Equal(t, "€1111,60", CurrencyOrDefault("EUR").Format(111160))
+	assert.Equal(t, "-£0.05", CurrencyOrDefault("GBP").Format(-5))
+}
+
+func TestCurrencyFormatRoundsToMinorUnits(t *testing.T) {
+	whole := Currency{Code: "XWH", Symbol: "W", MinorUnits: 0}
+	assert.Equal(t, "W1112", whole.Format(111160))
+
+	tenths := Currency{Code: "XTN", Symbol: "T", MinorUnits: 1, DecimalSeparator: "."}
+	assert.Equal(t, "T1111.6", tenths.Format(111160))
+	assert.Equal(t, "T1111.7", tenths.Format(111165))
+}
+
+func TestCurrencyFormatMajor(t *testing.T) {
+	assert.Equal(t, "£1000", CurrencyOrDefault("GBP").FormatMajor(1000))
+	assert.Equal(t, "€900", CurrencyOrDefault("EUR").FormatMajor(900))
+}
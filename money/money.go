@@ -0,0 +1,216 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// minorUnits is the number of minor units (pennies) in a major unit (pound).
+const minorUnits = 100
+
+// ErrNotFinite is returned when a calculated value, such as the result of
+// dividing zero by zero, is not a number, is infinite or is too large to be
+// held as an Amount.
+var ErrNotFinite = errors.New("money: the value is not a finite amount")
+
+// Amount represents an amount of money as a whole number of minor units, so
+// that adding and subtracting amounts is always exact.
+type Amount int64
+
+// FromMajor is a function used to convert a whole number of major units, such
+// as a loan amount in pounds, into an Amount.
+// Returns the Amount.
+func FromMajor(units int) Amount {
+	return Amount(int64(units) * minorUnits)
+}
+
+// FromFloat is a function used to convert a calculated value in major units
+// into an Amount, rounding to the nearest minor unit using the rounding mode.
+// Returns the rounded Amount, or ErrNotFinite if the value is NaN, infinite
+// or too large for an Amount.
+func FromFloat(f float64, mode RoundingMode) (Amount, error) {
+	minor := f * minorUnits
+	if math.IsNaN(minor) || math.Abs(minor) >= math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %g", ErrNotFinite, f)
+	}
+	return Amount(mode.Round(minor)), nil
+}
+
+// Multiply is a function used to multiply the amount by a rate, such as when
+// calculating interest, rounding to the nearest minor unit using the rounding
+// mode.
+// Returns the rounded Amount.
+func (a Amount) Multiply(rate float64, mode RoundingMode) Amount {
+	return Amount(mode.Round(float64(a) * rate))
+}
+
+// Float is a function used to convert the amount into major units.
+// Returns the amount as a float64, which may not be exact.
+func (a Amount) Float() float64 {
+	return float64(a) / minorUnits
+}
+
+// String is a public function to return a string representation of an amount
+// in major units with two decimal places, such as 1111.64. Used to satisfy
+// the fmt.Stringer interface.
+// Returns a string representing the amount.
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/minorUnits, a%minorUnits)
+}
+
+// MarshalJSON is used to satisfy the json.Marshaler interface. The amount is
+// written as a number in major units, such as 1111.64.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON is used to satisfy the json.Unmarshaler interface.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	parsed, err := Parse(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// MarshalYAML is used to satisfy the yaml.Marshaler interface. The amount is
+// written as a number in major units, such as 1111.64.
+func (a Amount) MarshalYAML() (interface{}, error) {
+	return a.Float(), nil
+}
+
+// UnmarshalYAML is used to satisfy the yaml.Unmarshaler interface.
+func (a *Amount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Parse is a function used to convert a string in major units, such as
+// "1111.64", into an Amount. No more than two decimal places are allowed, and
+// the only sign allowed is a single leading minus.
+// Returns the Amount if successful, or the associated error otherwise.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction := strings.TrimPrefix(s, "-"), ""
+	if i := strings.Index(whole, "."); i >= 0 {
+		whole, fraction = whole[:i], whole[i+1:]
+	}
+
+	// Only a single leading minus sign is allowed, so a sign left on the
+	// whole units, as in "--5" or "+5", is not valid.
+	if whole == "" || whole[0] == '-' || whole[0] == '+' || len(fraction) > 2 {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+
+	var minor int64
+	if fraction != "" {
+		// Pad so that "1.5" is treated as 1.50.
+		minor, err = strconv.ParseInt((fraction + "0")[:2], 10, 64)
+		if err != nil || fraction[0] == '-' || fraction[0] == '+' {
+			return 0, fmt.Errorf("money: invalid amount %q", s)
+		}
+	}
+
+	a := Amount(units*minorUnits + minor)
+	if negative {
+		a = -a
+	}
+	return a, nil
+}
+
+// RoundingMode represents the rule used to round a calculated value to a
+// whole number of minor units.
+type RoundingMode int
+
+const (
+	// HalfUp rounds halves away from zero. This is the default.
+	HalfUp RoundingMode = iota
+	// HalfEven rounds halves to the nearest even number, also known as
+	// banker's rounding.
+	HalfEven
+)
+
+// roundingModeNames maps each rounding mode to the name used in
+// configuration.
+var roundingModeNames = map[RoundingMode]string{
+	HalfUp:   "half-up",
+	HalfEven: "half-even",
+}
+
+// ParseRoundingMode is a function used to convert the name of a rounding mode,
+// half-up or half-even, into a RoundingMode.
+// Returns the RoundingMode if the name is recognised, or an error otherwise.
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	for mode, n := range roundingModeNames {
+		if n == strings.ToLower(name) {
+			return mode, nil
+		}
+	}
+	return HalfUp, fmt.Errorf("Unknown rounding mode %q", name)
+}
+
+// String is a public function to return the name of a rounding mode. Used to
+// satisfy the fmt.Stringer interface.
+func (m RoundingMode) String() string {
+	if n, ok := roundingModeNames[m]; ok {
+		return n
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// halfTolerance allows for floating point error when deciding whether a value
+// falls exactly half way between two whole numbers.
+const halfTolerance = 1e-9
+
+// Round is a function used to round f to a whole number using the rounding
+// mode.
+// Returns the rounded value.
+func (m RoundingMode) Round(f float64) int64 {
+	floor := math.Floor(f)
+	diff := f - floor
+
+	switch {
+	case diff < 0.5-halfTolerance:
+		return int64(floor)
+	case diff > 0.5+halfTolerance:
+		return int64(floor) + 1
+	}
+
+	// The value is a half, so the rounding mode decides.
+	if m == HalfEven {
+		if math.Mod(floor, 2) == 0 {
+			return int64(floor)
+		}
+		return int64(floor) + 1
+	}
+
+	// Round half away from zero.
+	if f < 0 {
+		return int64(floor)
+	}
+	return int64(floor) + 1
+}
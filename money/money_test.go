@@ -0,0 +1,109 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFromMajor(t *testing.T) {
+	assert.Equal(t, Amount(100000), FromMajor(1000), "£1000 should be 100000 pennies")
+}
+
+func TestFromFloatRoundsToNearestPenny(t *testing.T) {
+	for _, tc := range []struct {
+		f        float64
+		expected Amount
+	}{
+		{30.015815509, 3002},
+		{30.014999, 3001},
+		{-30.015815509, -3002},
+	} {
+		a, err := FromFloat(tc.f, HalfUp)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, a)
+	}
+}
+
+func TestFromFloatRejectsValuesThatAreNotFinite(t *testing.T) {
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e17} {
+		_, err := FromFloat(f, HalfUp)
+		assert.True(t, errors.Is(err, ErrNotFinite), "Expected %g to be rejected", f)
+	}
+}
+
+func TestRoundingModesDifferOnHalves(t *testing.T) {
+	assert.Equal(t, int64(3), HalfUp.Round(2.5), "Half up should round 2.5 up")
+	assert.Equal(t, int64(2), HalfEven.Round(2.5), "Half even should round 2.5 to 2")
+	assert.Equal(t, int64(4), HalfEven.Round(3.5), "Half even should round 3.5 to 4")
+	assert.Equal(t, int64(-3), HalfUp.Round(-2.5), "Half up should round away from zero")
+	assert.Equal(t, int64(-2), HalfEven.Round(-2.5), "Half even should round -2.5 to -2")
+	assert.Equal(t, int64(3), HalfEven.Round(2.6), "Non halves should round to nearest")
+}
+
+func TestMultiply(t *testing.T) {
+	// interest on £1000 at 5.1% / 12 = 4.25
+	assert.Equal(t, Amount(425), FromMajor(1000).Multiply(0.051/12, HalfUp))
+	// 0.5 pennies rounds differently per mode
+	assert.Equal(t, Amount(1), Amount(1).Multiply(0.5, HalfUp))
+	assert.Equal(t, Amount(0), Amount(1).Multiply(0.5, HalfEven))
+}
+
+func TestAmountString(t *testing.T) {
+	assert.Equal(t, "1111.64", Amount(111164).String())
+	assert.Equal(t, "0.05", Amount(5).String())
+	assert.Equal(t, "-0.05", Amount(-5).String())
+	assert.Equal(t, "30.00", Amount(3000).String())
+}
+
+func TestParse(t *testing.T) {
+	for s, expected := range map[string]Amount{
+		"1111.64": 111164,
+		"1.5":     150,
+		"30":      3000,
+		"-0.05":   -5,
+	} {
+		a, err := Parse(s)
+		assert.Nil(t, err, "Expected %s to parse", s)
+		assert.Equal(t, expected, a, "Unexpected amount for %s", s)
+	}
+
+	for _, s := range []string{"", "abc", "1.234", ".5", "1.-5", "--5", "-+5", "+-5", "+5", "-"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, "Expected %q to fail", s)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Total Amount }{111164})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"Total":1111.64}`, string(data))
+
+	var decoded struct{ Total Amount }
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, Amount(111164), decoded.Total)
+}
+
+func TestAmountYAML(t *testing.T) {
+	data, err := yaml.Marshal(struct{ Total Amount }{111164})
+	assert.Nil(t, err)
+	assert.Equal(t, "total: 1111.64\n", string(data))
+
+	var decoded struct{ Total Amount }
+	assert.Nil(t, yaml.Unmarshal([]byte("total: 49.5\n"), &decoded))
+	assert.Equal(t, Amount(4950), decoded.Total)
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	mode, err := ParseRoundingMode("Half-Even")
+	assert.Nil(t, err)
+	assert.Equal(t, HalfEven, mode)
+	assert.Equal(t, "half-even", mode.String())
+
+	_, err = ParseRoundingMode("down")
+	assert.NotNil(t, err)
+}
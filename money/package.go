@@ -0,0 +1,3 @@
+// Package money contains a fixed point type for amounts of money, along with
+// the rounding rules used when converting calculated values into amounts.
+package money
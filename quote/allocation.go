@@ -0,0 +1,67 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/eazynow/goquote/money"
+)
+
+// Allocation represents the part of a loan that is funded by a single lender.
+type Allocation struct {
+	// Lender is the name of the lender providing the funds.
+	Lender string `json:"lender" yaml:"lender"`
+	// Amount is how much is drawn from the lender.
+	Amount int `json:"amount" yaml:"amount"`
+	// Rate is the annual rate the lender charges.
+	Rate float64 `json:"rate" yaml:"rate"`
+	// MonthlyRepayment is the part of each monthly repayment owed to the lender.
+	MonthlyRepayment money.Amount `json:"monthly_repayment" yaml:"monthly_repayment"`
+	// TotalInterest is the interest the lender earns over the loan period.
+	TotalInterest money.Amount `json:"total_interest" yaml:"total_interest"`
+}
+
+// Allocations represents every lender allocation that makes up a quote, in
+// the order the lenders were drawn from.
+type Allocations []Allocation
+
+// Total is a function used to add up the amounts drawn from every lender.
+// Returns the total amount allocated.
+func (a Allocations) Total() int {
+	total := 0
+	for _, allocation := range a {
+		total += allocation.Amount
+	}
+	return total
+}
+
+// String is a public function to return a string representation of the
+// allocations in the money.DefaultCurrency. Used to satisfy the fmt.Stringer
+// interface.
+// Returns a string containing a table with one row per lender.
+func (a Allocations) String() string {
+	return a.Format(money.CurrencyOrDefault(money.DefaultCurrency))
+}
+
+// Format is a function used to display the allocations with amounts in the
+// given currency.
+// Returns a string containing a table with one row per lender.
+func (a Allocations) Format(c money.Currency) string {
+	var buf bytes.Buffer
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Lender\tAmount\tRate\tMonthly repayment\tTotal interest")
+	for _, l := range a {
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%s\t%s\n",
+			l.Lender,
+			c.FormatMajor(l.Amount),
+			l.Rate*100.0,
+			c.Format(l.MonthlyRepayment),
+			c.Format(l.TotalInterest))
+	}
+	w.Flush()
+
+	// Drop the trailing line break so it can be printed like a quote.
+	return string(bytes.TrimRight(buf.Bytes(), "\n"))
+}
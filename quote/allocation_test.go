@@ -0,0 +1,82 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteAllocationsRecordEachLender(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Fred", Rate: 0.071, Available: 500})
+
+	quote, err := NewQuote(1200, 36, lenders)
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+
+	// Fred is never reached as Jane and Bob cover the full amount.
+	assert.Equal(2, len(quote.Allocations), "Expected two lenders to be used")
+
+	assert.Equal("Jane", quote.Allocations[0].Lender, "Cheapest lender should be drawn first")
+	assert.Equal(1000, quote.Allocations[0].Amount)
+	assert.Equal(0.051, quote.Allocations[0].Rate)
+	// The monthly repayment of £36.18 is shared by the lenders. Jane is owed
+	// 30.015815509 and Bob 6.1662791696, so Bob gets the spare penny as he
+	// loses more by rounding down.
+	assert.Equal("30.01", quote.Allocations[0].MonthlyRepayment.String())
+	// total interest = sum of the monthly interest, rounded to the penny
+	assert.Equal("80.58", quote.Allocations[0].TotalInterest.String())
+
+	assert.Equal("Bob", quote.Allocations[1].Lender, "Next cheapest lender should be drawn second")
+	assert.Equal(200, quote.Allocations[1].Amount)
+	assert.Equal(0.069, quote.Allocations[1].Rate)
+	assert.Equal("6.17", quote.Allocations[1].MonthlyRepayment.String())
+	// total interest = sum of the monthly interest, rounded to the penny
+	assert.Equal("21.92", quote.Allocations[1].TotalInterest.String())
+}
+
+func TestQuoteAllocationsSumToQuote(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.075, Available: 640})
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.069, Available: 480})
+	lenders = append(lenders, lender.Lender{Name: "Fred", Rate: 0.071, Available: 520})
+
+	quote, err := NewQuote(1500, 36, lenders)
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+
+	amount := 0
+	var monthly, interest money.Amount
+	for _, a := range quote.Allocations {
+		amount += a.Amount
+		monthly += a.MonthlyRepayment
+		interest += a.TotalInterest
+	}
+
+	assert.Equal(1500, amount, "Allocations should cover the requested amount")
+	assert.Equal(quote.MonthlyRepayment, monthly,
+		"Allocations should add up to the monthly repayment")
+	assert.Equal(quote.TotalRepayment, money.FromMajor(1500)+interest,
+		"Allocations should add up to the total repayment")
+}
+
+func TestAllocationsStringReturnsCorrectFormat(t *testing.T) {
+	allocations := Allocations{
+		{"Jane", 1000, 0.051, 3002, 8057},
+		{"Bob", 200, 0.069, 617, 2199},
+	}
+
+	lines := strings.Split(allocations.String(), "\n")
+
+	assert.Equal(t, 3, len(lines), "Expected a header and a line per lender")
+	assert.Equal(t, "Lender  Amount  Rate  Monthly repayment  Total interest", lines[0])
+	assert.Equal(t, "Jane    £1000   5.1%  £30.02             £80.57", lines[1])
+	assert.Equal(t, "Bob     £200    6.9%  £6.17              £21.99", lines[2])
+}
@@ -0,0 +1,95 @@
+package quote
+
+import (
+	"math"
+)
+
+const (
+	// aprTolerance is how close the solved cash flows must be to the advance
+	// before the solver stops.
+	aprTolerance = 1e-9
+	// aprMaxIterations limits how long the solver can search for a rate.
+	aprMaxIterations = 200
+)
+
+// calculateAPR contains the formula for calculating the annual percentage rate
+// of charge of a loan. The advance is the amount the borrower actually
+// receives at the start of the loan, and payments are the amounts paid at the
+// end of each month.
+// The monthly rate r is the internal rate of return that makes the present
+// value of the payments equal to the advance:
+// advance = sum(payment_k / (1+r)^k)
+// It is solved using Newton's method, falling back to bisection if Newton's
+// method does not settle, and then annualised:
+// apr = (1+r)^12 - 1
+// Returns the APR as a fraction, such as 0.072 for 7.2%.
+func calculateAPR(advance float64, payments []float64) float64 {
+	if advance <= 0 || len(payments) == 0 {
+		return 0
+	}
+
+	// Repaying only the advance, such as from lenders charging no interest,
+	// has no charge for credit. The solver would only get close to zero.
+	total := 0.0
+	for _, p := range payments {
+		total += p
+	}
+	if math.Abs(total-advance) < aprTolerance {
+		return 0
+	}
+
+	// presentValue returns the present value of the payments at monthly rate
+	// r, less the advance, along with its derivative.
+	presentValue := func(r float64) (float64, float64) {
+		value, slope := -advance, 0.0
+		for i, p := range payments {
+			k := float64(i + 1)
+			value += p / math.Pow(1+r, k)
+			slope -= k * p / math.Pow(1+r, k+1)
+		}
+		return value, slope
+	}
+
+	// Start from a rate of 1% a month, which is close for most loans.
+	r := 0.01
+	for i := 0; i < aprMaxIterations; i++ {
+		value, slope := presentValue(r)
+		if math.Abs(value) < aprTolerance {
+			return annualise(r)
+		}
+		if slope == 0 {
+			break
+		}
+
+		next := r - value/slope
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		r = next
+	}
+
+	// Newton's method did not settle, so search between a rate just above -100%
+	// and one high enough to cover any real loan.
+	low, high := -0.99, 10.0
+	for i := 0; i < aprMaxIterations; i++ {
+		r = (low + high) / 2
+		value, _ := presentValue(r)
+		if math.Abs(value) < aprTolerance {
+			break
+		}
+
+		// The present value falls as the rate rises.
+		if value > 0 {
+			low = r
+		} else {
+			high = r
+		}
+	}
+
+	return annualise(r)
+}
+
+// annualise converts a monthly rate into an annual rate compounded monthly.
+func annualise(monthly float64) float64 {
+	return math.Pow(1+monthly, compoundFrequency) - 1
+}
@@ -0,0 +1,54 @@
+package quote
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateAPRMatchesNominalRateWithoutFees(t *testing.T) {
+	// £1000 over 12 months at 12% nominal is 1% a month, which annualises to
+	// (1.01)^12 - 1 = 12.68%.
+	payment := calculateMonthlyRate(0.12, 12, 1000)
+	payments := make([]float64, 12)
+	for i := range payments {
+		payments[i] = payment
+	}
+
+	apr := calculateAPR(1000, payments)
+
+	assert.Equal(t, "0.126825", fmt.Sprintf("%.6f", apr), "Expected APR to be 12.68%")
+}
+
+func TestCalculateAPRIncreasesWhenAdvanceIsLower(t *testing.T) {
+	payment := calculateMonthlyRate(0.12, 12, 1000)
+	payments := make([]float64, 12)
+	for i := range payments {
+		payments[i] = payment
+	}
+
+	// Receiving £950 but repaying as if £1000 was borrowed costs more.
+	assert.True(t, calculateAPR(950, payments) > calculateAPR(1000, payments))
+}
+
+func TestCalculateAPRHandlesNoPayments(t *testing.T) {
+	assert.Equal(t, 0.0, calculateAPR(1000, nil), "Expected no APR without payments")
+	assert.Equal(t, 0.0, calculateAPR(0, []float64{10}), "Expected no APR without an advance")
+}
+
+func TestQuoteIncludesAPR(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.069, Available: 200})
+
+	quote, err := NewQuote(1200, 36, lenders)
+
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	// blended nominal rate of 5.4%, compounded monthly
+	// apr = (1 + 0.054/12)^12 - 1 = 5.54%
+	assert.Equal(t, "0.055", fmt.Sprintf("%.3f", quote.APR), "Expected APR to be 5.5%")
+	assert.True(t, quote.APR > quote.Rate, "Expected APR to be above the nominal rate")
+}
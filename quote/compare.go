@@ -0,0 +1,83 @@
+package quote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+)
+
+// Comparison represents quotes for the same amount over different loan terms,
+// in the order the terms are listed in the policy. Terms the market cannot
+// fund are left out.
+type Comparison []*Quote
+
+// Compare is a function used to quote the amount in the request over every
+// term allowed by the policy, so the quotes can be compared side by side. If
+// the policy does not restrict the terms, only the requested term is quoted.
+// Terms that the market cannot fund, such as those longer than the lenders
+// will lend over, are skipped.
+// Returns a Comparison containing a quote for each term that can be funded
+// if successful. If no term can be funded, the InsufficientFundsError of the
+// first term is returned, and any other error is returned as soon as it is
+// found.
+func Compare(req QuoteRequest) (Comparison, error) {
+	policy := DefaultPolicy()
+	if req.Options.Policy != nil {
+		policy = *req.Options.Policy
+	}
+
+	terms := policy.Terms
+	if len(terms) == 0 {
+		terms = []int{req.Term}
+	}
+
+	var (
+		comparison Comparison
+		unfunded   error
+	)
+	for _, term := range terms {
+		req.Term = term
+
+		q, err := Calculate(req)
+		if errors.Is(err, ErrInsufficientFunds) {
+			if unfunded == nil {
+				unfunded = err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		comparison = append(comparison, q)
+	}
+
+	if len(comparison) == 0 {
+		return nil, unfunded
+	}
+	return comparison, nil
+}
+
+// String is a public function to return a string representation of a
+// comparison. Used to satisfy the fmt.Stringer interface.
+// Returns a string containing a table with one row per term.
+func (c Comparison) String() string {
+	var buf bytes.Buffer
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Term\tRate\tAPR\tMonthly repayment\tTotal repayment")
+	for _, q := range c {
+		currency := q.currency()
+		fmt.Fprintf(w, "%d months\t%.1f%%\t%.1f%%\t%s\t%s\n",
+			q.Term,
+			q.Rate*100.0,
+			q.APR*100.0,
+			currency.Format(q.MonthlyRepayment),
+			currency.Format(q.TotalRepayment))
+	}
+	w.Flush()
+
+	// Drop the trailing line break so it can be printed like a quote.
+	return string(bytes.TrimRight(buf.Bytes(), "\n"))
+}
@@ -0,0 +1,96 @@
+package quote
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareQuotesEveryAllowedTerm(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.069, Available: 200})
+
+	comparison, err := Compare(QuoteRequest{Amount: 1200, Lenders: lenders})
+
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+	assert.Equal(5, len(comparison), "Expected a quote for each default term")
+
+	for i, term := range DefaultPolicy().Terms {
+		assert.Equal(term, comparison[i].Term, "Expected quotes in policy order")
+	}
+
+	// The 36 month quote should match a standard quote.
+	assert.Equal("36.18", comparison[2].MonthlyRepayment.String())
+	assert.True(comparison[0].MonthlyRepayment > comparison[4].MonthlyRepayment,
+		"Shorter terms should have higher monthly repayments")
+}
+
+func TestCompareUsesRequestedTermWithoutPolicyTerms(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1200})
+
+	comparison, err := Compare(QuoteRequest{
+		Amount:  1200,
+		Term:    18,
+		Lenders: lenders,
+		Options: Options{Policy: &Policy{MinAmount: MinAmount, MaxAmount: MaxAmount}},
+	})
+
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+	assert.Equal(t, 1, len(comparison), "Expected a single quote")
+	assert.Equal(t, 18, comparison[0].Term)
+}
+
+func TestCompareSkipsTermsThatCannotBeFunded(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "A", Rate: 0.05, Available: 600, MaxTerm: 24})
+	lenders = append(lenders, lender.Lender{Name: "B", Rate: 0.06, Available: 600})
+
+	comparison, err := Compare(QuoteRequest{Amount: 1200, Lenders: lenders})
+
+	assert.Nil(t, err, "Expected the terms that can be funded to be compared")
+	if assert.Equal(t, 2, len(comparison)) {
+		assert.Equal(t, 12, comparison[0].Term)
+		assert.Equal(t, 24, comparison[1].Term)
+	}
+}
+
+func TestCompareFailsWhenNoTermCanBeFunded(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000})
+
+	comparison, err := Compare(QuoteRequest{Amount: 1200, Lenders: lenders})
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds), "Expected an error as pool amount is insufficient")
+	assert.Nil(t, comparison, "Expected no comparison due to the error")
+}
+
+func TestCompareFailsWhenRequestIsRejected(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000})
+
+	comparison, err := Compare(QuoteRequest{Amount: 50, Lenders: lenders})
+
+	assert.True(t, errors.Is(err, ErrAmountTooLow), "Expected the amount to be rejected")
+	assert.Nil(t, comparison)
+}
+
+func TestComparisonStringReturnsCorrectFormat(t *testing.T) {
+	comparison := Comparison{
+		{Term: 12, Rate: 0.054, APR: 0.0554, MonthlyRepayment: 10328, TotalRepayment: 123936},
+		{Term: 36, Rate: 0.054, APR: 0.0554, MonthlyRepayment: 3618, TotalRepayment: 130256},
+	}
+
+	lines := strings.Split(comparison.String(), "\n")
+
+	assert.Equal(t, 3, len(lines), "Expected a header and a line per term")
+	assert.Equal(t, "Term       Rate  APR   Monthly repayment  Total repayment", lines[0])
+	assert.Equal(t, "12 months  5.4%  5.5%  £103.28            £1239.36", lines[1])
+	assert.Equal(t, "36 months  5.4%  5.5%  £36.18             £1302.56", lines[2])
+}
@@ -0,0 +1,215 @@
+package quote
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eazynow/goquote/money"
+)
+
+// Sentinel errors returned, wrapped in one of the error structures below, when
+// a quote cannot be provided. They can be checked for using errors.Is.
+var (
+	// ErrAmountTooLow is used when the requested amount is below the policy
+	// minimum.
+	ErrAmountTooLow = errors.New("loan amount is too low")
+	// ErrAmountTooHigh is used when the requested amount is above the policy
+	// maximum.
+	ErrAmountTooHigh = errors.New("loan amount is too high")
+	// ErrAmountStep is used when the requested amount is not a multiple of the
+	// policy step.
+	ErrAmountStep = errors.New("loan amount is not a multiple of the step")
+	// ErrInvalidTerm is used when the requested term is not allowed by the
+	// policy.
+	ErrInvalidTerm = errors.New("loan term is not available")
+	// ErrInsufficientFunds is used when the pool of lenders cannot fund the
+	// requested amount.
+	ErrInsufficientFunds = errors.New("insufficient funds available")
+	// ErrCurrencyMismatch is used when the requested currency is not the
+	// currency of the market.
+	ErrCurrencyMismatch = errors.New("currency does not match the market")
+	// ErrFeesTooHigh is used when the fees deducted upfront would leave the
+	// borrower with nothing of the loan.
+	ErrFeesTooHigh = errors.New("upfront fees are not less than the loan amount")
+)
+
+// IsRejection is a function used to check whether an error means the quote
+// request breaks the policy, such as an amount that is too low or a term that
+// is not available, rather than that the market could not fund it or that
+// something went wrong.
+// Returns true if the error wraps one of the sentinel errors for a broken
+// rule.
+func IsRejection(err error) bool {
+	for _, rule := range []error{
+		ErrAmountTooLow,
+		ErrAmountTooHigh,
+		ErrAmountStep,
+		ErrInvalidTerm,
+		ErrCurrencyMismatch,
+		ErrFeesTooHigh,
+	} {
+		if errors.Is(err, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// AmountError is an error structure that is used when the requested amount
+// breaks one of the policy rules. The Cause is one of ErrAmountTooLow,
+// ErrAmountTooHigh or ErrAmountStep.
+type AmountError struct {
+	// Cause is the rule that the amount broke.
+	Cause error
+	// Amount is the amount that was requested.
+	Amount int
+	// Limit is the policy value the amount was checked against.
+	Limit int
+	// Currency is the code of the currency the amount was requested in.
+	Currency string
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns a string representation of the AmountError.
+func (e *AmountError) Error() string {
+	c := money.CurrencyOrDefault(e.Currency)
+
+	switch e.Cause {
+	case ErrAmountTooLow:
+		return fmt.Sprintf("Loan amount of %s is too low. Minimum loan amount is %s",
+			c.FormatMajor(e.Amount),
+			c.FormatMajor(e.Limit))
+	case ErrAmountTooHigh:
+		return fmt.Sprintf("Loan amount of %s is too high. Maximum loan amount is %s",
+			c.FormatMajor(e.Amount),
+			c.FormatMajor(e.Limit))
+	case ErrAmountStep:
+		return fmt.Sprintf("Loan amount must be a multiple of %s", c.FormatMajor(e.Limit))
+	}
+	return e.Cause.Error()
+}
+
+// Unwrap returns the Cause so the error can be checked using errors.Is.
+func (e *AmountError) Unwrap() error {
+	return e.Cause
+}
+
+// NewAmountError is a helper function for creating an amount error.
+// Returns a pointer to a new AmountError structure.
+func NewAmountError(cause error, amount, limit int, currency string) *AmountError {
+	return &AmountError{
+		Cause:    cause,
+		Amount:   amount,
+		Limit:    limit,
+		Currency: currency,
+	}
+}
+
+// TermError is an error structure that is used when the requested term is not
+// allowed by the policy.
+type TermError struct {
+	// Term is the term in months that was requested.
+	Term int
+	// Allowed is the list of terms the policy allows.
+	Allowed []int
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns a string representation of the TermError.
+func (e *TermError) Error() string {
+	return fmt.Sprintf("Loan term of %d months is not available", e.Term)
+}
+
+// Unwrap returns ErrInvalidTerm so the error can be checked using errors.Is.
+func (e *TermError) Unwrap() error {
+	return ErrInvalidTerm
+}
+
+// InsufficientFundsError is an error structure that is used when the pool of
+// lenders cannot fund the requested amount.
+type InsufficientFundsError struct {
+	// Requested is the amount that was requested.
+	Requested int
+	// Shortfall is the part of the requested amount that could not be funded.
+	Shortfall int
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns a string representation of the InsufficientFundsError.
+func (e *InsufficientFundsError) Error() string {
+	return "It is not possible to provide a quote at this time."
+}
+
+// Unwrap returns ErrInsufficientFunds so the error can be checked using
+// errors.Is.
+func (e *InsufficientFundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// CurrencyError is an error structure that is used when the requested
+// currency is not the currency the market lends in.
+type CurrencyError struct {
+	// Requested is the code of the currency that was requested.
+	Requested string
+	// Market is the code of the currency the market lends in.
+	Market string
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns a string representation of the CurrencyError.
+func (e *CurrencyError) Error() string {
+	return fmt.Sprintf("Loans in %s are not available. The market lends in %s",
+		e.Requested,
+		e.Market)
+}
+
+// Unwrap returns ErrCurrencyMismatch so the error can be checked using
+// errors.Is.
+func (e *CurrencyError) Unwrap() error {
+	return ErrCurrencyMismatch
+}
+
+// FeeError is an error structure that is used when the fees deducted upfront
+// are at least the amount that would be lent.
+type FeeError struct {
+	// Upfront is the total of the fees deducted upfront.
+	Upfront money.Amount
+	// Amount is the amount that would be lent.
+	Amount int
+	// Currency is the code of the currency of the loan.
+	Currency string
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns a string representation of the FeeError.
+func (e *FeeError) Error() string {
+	c := money.CurrencyOrDefault(e.Currency)
+
+	return fmt.Sprintf("Upfront fees of %s are not less than the loan amount of %s",
+		c.Format(e.Upfront),
+		c.FormatMajor(e.Amount))
+}
+
+// Unwrap returns ErrFeesTooHigh so the error can be checked using errors.Is.
+func (e *FeeError) Unwrap() error {
+	return ErrFeesTooHigh
+}
+
+// RequestError is an error structure that is used when the RequestParams
+// given to an API cannot be understood, such as an unknown strategy.
+type RequestError struct {
+	// Message describes what is wrong with the request.
+	Message string
+}
+
+// The Error function is used to satisfy the error interface.
+// Returns the Message of the RequestError.
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// NewRequestError is a helper function for creating a request error.
+// Returns a pointer to a new RequestError structure.
+func NewRequestError(message string) *RequestError {
+	return &RequestError{Message: message}
+}
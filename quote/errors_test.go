@@ -0,0 +1,90 @@
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteValidateReturnsTypedErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	q := Quote{}
+
+	q.RequestedAmount = MinAmount - 100
+	err := q.validate()
+	assert.True(errors.Is(err, ErrAmountTooLow), "Expected ErrAmountTooLow")
+	assert.Equal("Loan amount of £900 is too low. Minimum loan amount is £1000", err.Error())
+
+	var amountErr *AmountError
+	assert.True(errors.As(err, &amountErr), "Expected an AmountError")
+	assert.Equal(900, amountErr.Amount)
+	assert.Equal(MinAmount, amountErr.Limit)
+
+	q.RequestedAmount = MaxAmount + 100
+	err = q.validate()
+	assert.True(errors.Is(err, ErrAmountTooHigh), "Expected ErrAmountTooHigh")
+	assert.Equal("Loan amount of £15100 is too high. Maximum loan amount is £15000", err.Error())
+
+	q.RequestedAmount = MinAmount + 10
+	err = q.validate()
+	assert.True(errors.Is(err, ErrAmountStep), "Expected ErrAmountStep")
+	assert.Equal("Loan amount must be a multiple of £100", err.Error())
+}
+
+func TestQuoteValidateReturnsTermError(t *testing.T) {
+	q := Quote{}
+	q.RequestedAmount = MinAmount
+	q.Term = 18
+	q.policy = &Policy{MinAmount: MinAmount, MaxAmount: MaxAmount, Terms: []int{12, 36}}
+
+	err := q.validate()
+
+	assert.True(t, errors.Is(err, ErrInvalidTerm), "Expected ErrInvalidTerm")
+
+	var termErr *TermError
+	assert.True(t, errors.As(err, &termErr), "Expected a TermError")
+	assert.Equal(t, 18, termErr.Term)
+	assert.Equal(t, []int{12, 36}, termErr.Allowed)
+}
+
+func TestNewQuoteReturnsInsufficientFundsError(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.01, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.01, Available: 200})
+
+	_, err := NewQuote(1500, 36, lenders)
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds), "Expected ErrInsufficientFunds")
+	assert.Equal(t, "It is not possible to provide a quote at this time.", err.Error())
+
+	var fundsErr *InsufficientFundsError
+	assert.True(t, errors.As(err, &fundsErr), "Expected an InsufficientFundsError")
+	assert.Equal(t, 1500, fundsErr.Requested)
+	assert.Equal(t, 300, fundsErr.Shortfall)
+}
+
+func TestIsRejection(t *testing.T) {
+	for _, err := range []error{
+		NewAmountError(ErrAmountTooLow, 900, MinAmount, "GBP"),
+		NewAmountError(ErrAmountStep, 1050, 100, "GBP"),
+		&TermError{Term: 18},
+		&CurrencyError{Requested: "EUR", Market: "GBP"},
+		&FeeError{Upfront: 100000, Amount: 1000, Currency: "GBP"},
+		fmt.Errorf("quote failed: %w", &TermError{Term: 18}),
+	} {
+		assert.True(t, IsRejection(err), "Expected %q to be a rejection", err)
+	}
+
+	for _, err := range []error{
+		nil,
+		&InsufficientFundsError{Requested: 1500, Shortfall: 300},
+		lender.ErrMixedCurrencies,
+		NewRequestError("Unknown allocation strategy"),
+	} {
+		assert.False(t, IsRejection(err), "Expected %v not to be a rejection", err)
+	}
+}
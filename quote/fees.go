@@ -0,0 +1,143 @@
+package quote
+
+import (
+	"fmt"
+
+	"github.com/eazynow/goquote/money"
+)
+
+// OriginationMode represents how an origination fee is paid.
+type OriginationMode string
+
+const (
+	// OriginationDeducted takes the origination fee out of the amount paid to
+	// the borrower. This is the default.
+	OriginationDeducted OriginationMode = "deducted"
+	// OriginationAdded adds the origination fee to the loan, so that it is
+	// repaid with interest at the blended rate over the term.
+	OriginationAdded OriginationMode = "added"
+)
+
+// FeeRules represents the fees charged by the platform on a loan. They are
+// part of the Policy so they can be changed without a code release. A zero
+// value charges no fees.
+type FeeRules struct {
+	// Arrangement is a fixed fee deducted from the amount paid to the
+	// borrower.
+	Arrangement money.Amount `json:"arrangement" yaml:"arrangement"`
+	// Origination is a fee charged as a fraction between 0 and 1 of the
+	// funded amount, which is less than the requested amount for a partial
+	// quote.
+	Origination float64 `json:"origination" yaml:"origination"`
+	// OriginationMode is how the origination fee is paid. An empty mode means
+	// OriginationDeducted.
+	OriginationMode OriginationMode `json:"origination_mode" yaml:"origination_mode"`
+	// Servicing is a fixed fee added to every monthly repayment.
+	Servicing money.Amount `json:"servicing" yaml:"servicing"`
+}
+
+// validate is a private function that checks the fee rules make sense.
+// Returns an error describing the first problem found, or nil otherwise.
+func (r FeeRules) validate() error {
+	if r.Arrangement < 0 || r.Servicing < 0 {
+		return fmt.Errorf("Fees cannot be negative")
+	}
+
+	if r.Origination < 0 || r.Origination >= 1 {
+		return fmt.Errorf("Origination fee of %g is not between 0 and 1", r.Origination)
+	}
+
+	switch r.OriginationMode {
+	case "", OriginationDeducted, OriginationAdded:
+		return nil
+	}
+
+	return fmt.Errorf("Unknown origination fee mode %q", r.OriginationMode)
+}
+
+// FeeType represents the kind of a fee charged on a quote.
+type FeeType string
+
+// The types of fee that can be charged on a quote.
+const (
+	FeeArrangement FeeType = "arrangement"
+	FeeOrigination FeeType = "origination"
+	FeeServicing   FeeType = "servicing"
+)
+
+// FeeTiming represents when a fee is paid.
+type FeeTiming string
+
+const (
+	// FeeUpfront fees are deducted from the amount paid to the borrower.
+	FeeUpfront FeeTiming = "upfront"
+	// FeeFinanced fees are added to the loan and repaid over the term.
+	FeeFinanced FeeTiming = "financed"
+	// FeeMonthly fees are added to every monthly repayment.
+	FeeMonthly FeeTiming = "monthly"
+)
+
+// Fee represents a single fee charged on a quote.
+type Fee struct {
+	// Type is the kind of fee.
+	Type FeeType `json:"type" yaml:"type"`
+	// Timing is when the fee is paid.
+	Timing FeeTiming `json:"timing" yaml:"timing"`
+	// Amount is the fee charged each time it is paid.
+	Amount money.Amount `json:"amount" yaml:"amount"`
+	// Total is the fee charged over the whole loan.
+	Total money.Amount `json:"total" yaml:"total"`
+}
+
+// feeLabels are the names of each fee type used in the text output.
+var feeLabels = map[FeeType]string{
+	FeeArrangement: "Arrangement fee",
+	FeeOrigination: "Origination fee",
+	FeeServicing:   "Servicing fee",
+}
+
+// Format is a function used to describe the fee in the given currency, such
+// as "Servicing fee: £5.00 a month (£180.00 in total)".
+// Returns a string describing the fee.
+func (f Fee) Format(c money.Currency) string {
+	switch f.Timing {
+	case FeeFinanced:
+		return fmt.Sprintf("%s: %s added to the loan", feeLabels[f.Type], c.Format(f.Amount))
+	case FeeMonthly:
+		return fmt.Sprintf("%s: %s a month (%s in total)", feeLabels[f.Type], c.Format(f.Amount), c.Format(f.Total))
+	}
+	return fmt.Sprintf("%s: %s deducted upfront", feeLabels[f.Type], c.Format(f.Amount))
+}
+
+// charges is a private function that works out the fees for a loan of amount
+// over term months.
+// Returns the itemised fees, the total deducted upfront and the total added
+// to the loan.
+func (r FeeRules) charges(amount, term int, mode money.RoundingMode) (fees []Fee, upfront, financed money.Amount) {
+	if r.Arrangement > 0 {
+		fees = append(fees, Fee{Type: FeeArrangement, Timing: FeeUpfront, Amount: r.Arrangement, Total: r.Arrangement})
+		upfront += r.Arrangement
+	}
+
+	if origination := money.FromMajor(amount).Multiply(r.Origination, mode); origination > 0 {
+		fee := Fee{Type: FeeOrigination, Timing: FeeUpfront, Amount: origination, Total: origination}
+		if r.OriginationMode == OriginationAdded {
+			fee.Timing = FeeFinanced
+			financed += origination
+		} else {
+			upfront += origination
+		}
+		fees = append(fees, fee)
+	}
+
+	if r.Servicing > 0 {
+		fees = append(fees, Fee{
+			Type:   FeeServicing,
+			Timing: FeeMonthly,
+			Amount: r.Servicing,
+			Total:  r.Servicing * money.Amount(term),
+		})
+	}
+
+	return fees, upfront, financed
+}
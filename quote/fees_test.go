@@ -0,0 +1,184 @@
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFeeQuote creates the same quote as newTestQuote, charging the given
+// fees.
+func newFeeQuote(t *testing.T, fees FeeRules) *Quote {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+
+	policy := DefaultPolicy()
+	policy.Fees = fees
+
+	q, err := Calculate(QuoteRequest{
+		Amount:  1200,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{
+			StartDate: time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC),
+			Policy:    &policy,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestFeeRulesValidate(t *testing.T) {
+	assert.Nil(t, FeeRules{}.validate(), "No fees should be valid")
+	assert.Nil(t, FeeRules{Origination: 0.02, OriginationMode: OriginationAdded}.validate())
+	assert.NotNil(t, FeeRules{Arrangement: -1}.validate(), "Expected an error as the fee is negative")
+	assert.NotNil(t, FeeRules{Origination: 1}.validate(), "Expected an error as the fee is the whole loan")
+	assert.NotNil(t, FeeRules{OriginationMode: "later"}.validate(), "Expected an error as the mode is unknown")
+}
+
+func TestFeeRulesCharges(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := FeeRules{Arrangement: 5000, Origination: 0.02, Servicing: 150}
+	fees, upfront, financed := rules.charges(1200, 36, money.HalfUp)
+
+	assert.Equal([]Fee{
+		{Type: FeeArrangement, Timing: FeeUpfront, Amount: 5000, Total: 5000},
+		{Type: FeeOrigination, Timing: FeeUpfront, Amount: 2400, Total: 2400},
+		{Type: FeeServicing, Timing: FeeMonthly, Amount: 150, Total: 5400},
+	}, fees)
+	assert.Equal(money.Amount(7400), upfront, "Arrangement and origination are deducted upfront")
+	assert.Equal(money.Amount(0), financed)
+
+	rules.OriginationMode = OriginationAdded
+	fees, upfront, financed = rules.charges(1200, 36, money.HalfUp)
+
+	assert.Equal(FeeFinanced, fees[1].Timing)
+	assert.Equal(money.Amount(5000), upfront)
+	assert.Equal(money.Amount(2400), financed, "Origination is added to the loan")
+}
+
+func TestCalculateWithoutFees(t *testing.T) {
+	q := newTestQuote(t)
+
+	assert.Nil(t, q.Fees)
+	assert.Equal(t, money.FromMajor(1200), q.AmountReceived)
+	assert.Equal(t, q.TotalRepayment-money.FromMajor(1200), q.TotalCostOfCredit)
+}
+
+func TestCalculateDeductsUpfrontFees(t *testing.T) {
+	assert := assert.New(t)
+	plain := newTestQuote(t)
+	q := newFeeQuote(t, FeeRules{Arrangement: 5000, Origination: 0.02})
+
+	// The repayments are unchanged, but the borrower receives less.
+	assert.Equal(plain.MonthlyRepayment, q.MonthlyRepayment)
+	assert.Equal(plain.TotalRepayment, q.TotalRepayment)
+	assert.Equal("1126.00", q.AmountReceived.String(), "Expected £1200 less £50 and 2%")
+	assert.Equal(q.TotalRepayment-q.AmountReceived, q.TotalCostOfCredit)
+	assert.True(q.APR > plain.APR, "Expected the fees to increase the APR")
+	assert.Equal(plain.Rate, q.Rate, "Fees should not change the nominal rate")
+}
+
+func TestCalculateFailsWhenUpfrontFeesTakeWholeLoan(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 2000})
+
+	for _, fees := range []FeeRules{
+		{Arrangement: money.FromMajor(1000)},
+		{Arrangement: money.FromMajor(990), Origination: 0.02},
+	} {
+		policy := DefaultPolicy()
+		policy.Fees = fees
+
+		_, err := Calculate(QuoteRequest{Amount: 1000, Term: 36, Lenders: lenders, Options: Options{Policy: &policy}})
+
+		var feeErr *FeeError
+		if assert.True(t, errors.As(err, &feeErr), "Expected a FeeError for %+v", fees) {
+			assert.True(t, errors.Is(err, ErrFeesTooHigh))
+			assert.Equal(t, 1000, feeErr.Amount)
+		}
+	}
+
+	policy := DefaultPolicy()
+	policy.Fees = FeeRules{Arrangement: money.FromMajor(1000)}
+	_, err := Calculate(QuoteRequest{Amount: 1000, Term: 36, Lenders: lenders, Options: Options{Policy: &policy}})
+	assert.Equal(t, "Upfront fees of £1000.00 are not less than the loan amount of £1000", err.Error())
+}
+
+func TestCalculateAddsOriginationFeeToLoan(t *testing.T) {
+	assert := assert.New(t)
+	plain := newTestQuote(t)
+	q := newFeeQuote(t, FeeRules{Origination: 0.02, OriginationMode: OriginationAdded})
+
+	assert.Equal(money.FromMajor(1200), q.AmountReceived, "Expected the borrower to receive the full amount")
+	assert.True(q.MonthlyRepayment > plain.MonthlyRepayment, "Expected the fee to be repaid monthly")
+	assert.True(q.APR > plain.APR, "Expected the fee to increase the APR")
+
+	// The fee is repaid along with the loan, so the schedule still reconciles.
+	var principal, total money.Amount
+	for _, p := range q.Schedule(q.StartDate) {
+		principal += p.Principal
+		total += p.Amount
+	}
+	assert.Equal(money.FromMajor(1200)+2400, principal, "Expected the loan and the fee to be repaid")
+	assert.Equal(q.TotalRepayment, total)
+
+	// The lenders are only owed what they lent, give or take the penny that
+	// is shared with the fee.
+	shares := money.Amount(0)
+	for _, a := range q.Allocations {
+		shares += a.MonthlyRepayment
+	}
+	assert.InDelta(float64(plain.MonthlyRepayment), float64(shares), 1)
+}
+
+func TestCalculateAddsServicingFeeToPayments(t *testing.T) {
+	assert := assert.New(t)
+	plain := newTestQuote(t)
+	q := newFeeQuote(t, FeeRules{Servicing: 150})
+
+	assert.Equal(plain.MonthlyRepayment+150, q.MonthlyRepayment)
+	assert.Equal(plain.TotalRepayment+150*36, q.TotalRepayment)
+
+	schedule := q.Schedule(q.StartDate)
+	assert.Equal(money.Amount(150), schedule[0].Fee)
+	assert.Equal(q.MonthlyRepayment, schedule[0].Amount)
+	assert.Contains(schedule.String(), "Fee", "Expected a fee column in the schedule")
+	assert.NotContains(plain.Schedule(plain.StartDate).String(), "Fee", "Expected no fee column without fees")
+}
+
+func TestQuoteStringItemisesFees(t *testing.T) {
+	q := newFeeQuote(t, FeeRules{Arrangement: 5000, Origination: 0.02, OriginationMode: OriginationAdded, Servicing: 150})
+
+	lines := strings.Split(q.String(), "\n")
+
+	assert.Equal(t, 10, len(lines), "Expected a line per fee and the totals")
+	assert.Equal(t, "Arrangement fee: £50.00 deducted upfront", lines[5])
+	assert.Equal(t, "Origination fee: £24.00 added to the loan", lines[6])
+	assert.Equal(t, "Servicing fee: £1.50 a month (£54.00 in total)", lines[7])
+	assert.Equal(t, "Amount received: £1150.00", lines[8])
+	assert.Equal(t, fmt.Sprintf("Total cost of credit: £%s", q.TotalCostOfCredit), lines[9])
+}
+
+func TestLoadPolicyWithFees(t *testing.T) {
+	policy, err := LoadPolicy("test_fees.yaml")
+
+	assert.Nil(t, err, "Expected file to work but it failed")
+	assert.Equal(t, FeeRules{
+		Arrangement:     5000,
+		Origination:     0.02,
+		OriginationMode: OriginationAdded,
+		Servicing:       150,
+	}, policy.Fees)
+	assert.Equal(t, MinAmount, policy.MinAmount, "Missing rules should keep the default")
+}
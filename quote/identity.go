@@ -0,0 +1,34 @@
+package quote
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// DefaultValidity is how long a quote is valid for when no validity is set in
+// the quote Options.
+const DefaultValidity = 24 * time.Hour
+
+// idBytes is the number of random bytes in a quote ID.
+const idBytes = 16
+
+// NewID is a function used to create a random ID, such as the ID of a quote.
+// It is exported so that the reservations for quotes can use the same kind of
+// ID.
+// Returns the ID as a hex string, or the associated error if the random
+// number generator fails.
+func NewID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Expired is a function used to check whether the quote is no longer valid
+// at the given time.
+// Returns true if the quote has expired.
+func (q *Quote) Expired(at time.Time) bool {
+	return !at.Before(q.ExpiresAt)
+}
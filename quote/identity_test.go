@@ -0,0 +1,61 @@
+package quote
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIDIsUnique(t *testing.T) {
+	a, err := NewID()
+	assert.Nil(t, err)
+	b, err := NewID()
+	assert.Nil(t, err)
+
+	assert.Equal(t, idBytes*2, len(a), "Expected a hex string")
+	assert.NotEqual(t, a, b, "Expected every ID to be different")
+}
+
+func TestCalculateRecordsMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+
+	created := time.Date(2015, time.January, 15, 12, 0, 0, 0, time.UTC)
+	policy := DefaultPolicy()
+	policy.Version = "2015-01"
+
+	q, err := Calculate(QuoteRequest{
+		Amount:  1200,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{CreatedAt: created, ValidFor: time.Hour, Policy: &policy},
+	})
+
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+	assert.NotEmpty(q.ID)
+	assert.Equal(created, q.CreatedAt)
+	assert.Equal(created, q.StartDate, "Expected the start date to default to the creation time")
+	assert.Equal(created.Add(time.Hour), q.ExpiresAt)
+	assert.Equal("2015-01", q.PolicyVersion)
+	assert.Equal(lenders.Hash(), q.MarketHash)
+
+	again, err := Calculate(QuoteRequest{Amount: 1200, Term: 36, Lenders: lenders})
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+	assert.NotEqual(q.ID, again.ID, "Expected every quote to have its own ID")
+	assert.Equal(q.MarketHash, again.MarketHash, "Expected the same market to have the same hash")
+	assert.Equal(DefaultPolicyVersion, again.PolicyVersion)
+	assert.Equal(again.CreatedAt.Add(DefaultValidity), again.ExpiresAt)
+}
+
+func TestQuoteExpired(t *testing.T) {
+	created := time.Date(2015, time.January, 15, 12, 0, 0, 0, time.UTC)
+	q := Quote{CreatedAt: created, ExpiresAt: created.Add(time.Hour)}
+
+	assert.False(t, q.Expired(created.Add(time.Minute)))
+	assert.True(t, q.Expired(created.Add(time.Hour)))
+}
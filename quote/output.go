@@ -0,0 +1,216 @@
+package quote
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Format represents an output format that a quote can be written in.
+type Format string
+
+// The output formats supported by the Encode functions.
+const (
+	// FormatText is the human readable format produced by the String functions.
+	FormatText Format = "text"
+	// FormatJSON writes the quote as a JSON document.
+	FormatJSON Format = "json"
+	// FormatYAML writes the quote as a YAML document.
+	FormatYAML Format = "yaml"
+	// FormatCSV writes the quote as csv tables separated by blank lines.
+	FormatCSV Format = "csv"
+)
+
+// Formats is the list of every supported output format.
+var Formats = []Format{FormatText, FormatJSON, FormatYAML, FormatCSV}
+
+// ParseFormat is a function used to convert the name of an output format,
+// such as one passed on the command line, into a Format.
+// Returns the Format if the name is recognised, or an error otherwise.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown output format %q", name)
+}
+
+// report is the structure written by the structured output formats. The
+// schedule is only included when requested.
+type report struct {
+	Quote    *Quote   `json:"quote" yaml:"quote"`
+	Schedule Schedule `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+}
+
+// Encode is a function used to write the quote to w in the given format. The
+// allocations are always included in the structured formats, and the
+// repayment schedule is included when withSchedule is true.
+// Returns any error found when writing the quote.
+func (q *Quote) Encode(w io.Writer, format Format, withSchedule bool) error {
+	r := report{Quote: q}
+	if withSchedule {
+		r.Schedule = q.Schedule(q.StartDate)
+	}
+
+	switch format {
+	case FormatText:
+		currency := q.currency()
+		s := []string{q.String(), q.Allocations.Format(currency)}
+		if withSchedule {
+			s = append(s, r.Schedule.Format(currency))
+		}
+		_, err := fmt.Fprintln(w, strings.Join(s, "\n\n"))
+		return err
+	case FormatJSON:
+		return encodeJSON(w, r)
+	case FormatYAML:
+		return encodeYAML(w, r)
+	case FormatCSV:
+		return r.encodeCSV(w)
+	}
+
+	return fmt.Errorf("Unknown output format %q", format)
+}
+
+// Encode is a function used to write the comparison to w in the given
+// format.
+// Returns any error found when writing the comparison.
+func (c Comparison) Encode(w io.Writer, format Format) error {
+	switch format {
+	case FormatText:
+		_, err := fmt.Fprintln(w, c.String())
+		return err
+	case FormatJSON:
+		return encodeJSON(w, c)
+	case FormatYAML:
+		return encodeYAML(w, c)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(quoteCSVHeader)
+		for _, q := range c {
+			cw.Write(q.csvRecord())
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return fmt.Errorf("Unknown output format %q", format)
+}
+
+// encodeJSON writes v to w as indented JSON.
+func encodeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// encodeYAML writes v to w as YAML.
+func encodeYAML(w io.Writer, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// The csv headers for each of the tables written by the csv format.
+var (
+	quoteCSVHeader      = []string{"requested_amount", "currency", "term", "start_date", "rate", "apr", "monthly_repayment", "total_repayment", "amount_received", "total_cost_of_credit", "funded_amount", "shortfall", "id", "created_at", "expires_at", "policy_version", "market_hash"}
+	feeCSVHeader        = []string{"type", "timing", "amount", "total"}
+	allocationCSVHeader = []string{"lender", "amount", "rate", "monthly_repayment", "total_interest"}
+	paymentCSVHeader    = []string{"number", "date", "amount", "interest", "principal", "fee", "balance"}
+)
+
+// encodeCSV writes the report to w as csv. The quote, allocations, fees and
+// schedule are written as separate tables, each with a header row, separated
+// by blank lines. The fees table is only written when fees are charged.
+func (r report) encodeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write(quoteCSVHeader)
+	cw.Write(r.Quote.csvRecord())
+
+	cw.Flush()
+	io.WriteString(w, "\n")
+	cw.Write(allocationCSVHeader)
+	for _, a := range r.Quote.Allocations {
+		cw.Write([]string{
+			a.Lender,
+			strconv.Itoa(a.Amount),
+			formatFloat(a.Rate),
+			a.MonthlyRepayment.String(),
+			a.TotalInterest.String(),
+		})
+	}
+
+	if len(r.Quote.Fees) > 0 {
+		cw.Flush()
+		io.WriteString(w, "\n")
+		cw.Write(feeCSVHeader)
+		for _, f := range r.Quote.Fees {
+			cw.Write([]string{
+				string(f.Type),
+				string(f.Timing),
+				f.Amount.String(),
+				f.Total.String(),
+			})
+		}
+	}
+
+	if r.Schedule != nil {
+		cw.Flush()
+		io.WriteString(w, "\n")
+		cw.Write(paymentCSVHeader)
+		for _, p := range r.Schedule {
+			cw.Write([]string{
+				strconv.Itoa(p.Number),
+				p.Date.Format(scheduleDateFormat),
+				p.Amount.String(),
+				p.Interest.String(),
+				p.Principal.String(),
+				p.Fee.String(),
+				p.Balance.String(),
+			})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvRecord returns the quote summary as a csv record matching
+// quoteCSVHeader.
+func (q *Quote) csvRecord() []string {
+	return []string{
+		strconv.Itoa(q.RequestedAmount),
+		q.Currency,
+		strconv.Itoa(q.Term),
+		q.StartDate.Format(scheduleDateFormat),
+		formatFloat(q.Rate),
+		formatFloat(q.APR),
+		q.MonthlyRepayment.String(),
+		q.TotalRepayment.String(),
+		q.AmountReceived.String(),
+		q.TotalCostOfCredit.String(),
+		strconv.Itoa(q.FundedAmount),
+		strconv.Itoa(q.Shortfall),
+		q.ID,
+		q.CreatedAt.Format(time.RFC3339),
+		q.ExpiresAt.Format(time.RFC3339),
+		q.PolicyVersion,
+		q.MarketHash,
+	}
+}
+
+// formatFloat formats a float for csv output without losing precision.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
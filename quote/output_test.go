@@ -0,0 +1,175 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+// newTestQuote creates a quote used by the output tests.
+func newTestQuote(t *testing.T) *Quote {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+
+	q, err := Calculate(QuoteRequest{
+		Amount:  1200,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{StartDate: time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("JSON")
+	assert.Nil(t, err, "Expected format names to be case insensitive")
+	assert.Equal(t, FormatJSON, f)
+
+	_, err = ParseFormat("xml")
+	assert.NotNil(t, err, "Expected an error as xml is not supported")
+}
+
+func TestQuoteEncodeJSON(t *testing.T) {
+	assert := assert.New(t)
+	q := newTestQuote(t)
+
+	var buf bytes.Buffer
+	err := q.Encode(&buf, FormatJSON, false)
+	assert.Nil(err, "Expected the quote to encode")
+
+	var decoded map[string]map[string]interface{}
+	assert.Nil(json.Unmarshal(buf.Bytes(), &decoded), "Expected valid JSON")
+
+	out := decoded["quote"]
+	assert.Equal(1200.0, out["requested_amount"])
+	assert.Equal(36.0, out["term"])
+	assert.Equal("2015-01-15T00:00:00Z", out["start_date"])
+	assert.Equal(2, len(out["allocations"].([]interface{})), "Expected the allocations")
+	assert.NotContains(buf.String(), "schedule", "Schedule should only be included on request")
+}
+
+func TestQuoteEncodeJSONWithSchedule(t *testing.T) {
+	q := newTestQuote(t)
+
+	var buf bytes.Buffer
+	err := q.Encode(&buf, FormatJSON, true)
+	assert.Nil(t, err, "Expected the quote to encode")
+
+	var decoded struct {
+		Schedule []Payment `json:"schedule"`
+	}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded), "Expected valid JSON")
+	assert.Equal(t, 36, len(decoded.Schedule), "Expected the schedule to be included")
+	assert.Equal(t, 1, decoded.Schedule[0].Number)
+}
+
+func TestQuoteEncodeYAML(t *testing.T) {
+	assert := assert.New(t)
+	q := newTestQuote(t)
+
+	var buf bytes.Buffer
+	err := q.Encode(&buf, FormatYAML, true)
+	assert.Nil(err, "Expected the quote to encode")
+
+	var decoded struct {
+		Quote struct {
+			RequestedAmount int `yaml:"requested_amount"`
+			Allocations     []struct {
+				Lender string `yaml:"lender"`
+				Amount int    `yaml:"amount"`
+			} `yaml:"allocations"`
+		} `yaml:"quote"`
+		Schedule []interface{} `yaml:"schedule"`
+	}
+	assert.Nil(yaml.Unmarshal(buf.Bytes(), &decoded), "Expected valid YAML")
+	assert.Equal(1200, decoded.Quote.RequestedAmount)
+	assert.Equal("Jane", decoded.Quote.Allocations[0].Lender)
+	assert.Equal(1000, decoded.Quote.Allocations[0].Amount)
+	assert.Equal(36, len(decoded.Schedule))
+}
+
+func TestQuoteEncodeCSV(t *testing.T) {
+	assert := assert.New(t)
+	q := newTestQuote(t)
+
+	var buf bytes.Buffer
+	err := q.Encode(&buf, FormatCSV, true)
+	assert.Nil(err, "Expected the quote to encode")
+
+	tables := strings.Split(buf.String(), "\n\n")
+	assert.Equal(3, len(tables), "Expected quote, allocation and schedule tables")
+
+	quote, err := csv.NewReader(strings.NewReader(tables[0])).ReadAll()
+	assert.Nil(err, "Expected valid csv")
+	assert.Equal(quoteCSVHeader, quote[0])
+	assert.Equal([]string{"1200", "GBP", "36", "2015-01-15"}, quote[1][:4])
+
+	allocations, err := csv.NewReader(strings.NewReader(tables[1])).ReadAll()
+	assert.Nil(err, "Expected valid csv")
+	assert.Equal(3, len(allocations), "Expected a header and a row per lender")
+	assert.Equal([]string{"Jane", "1000", "0.051"}, allocations[1][:3])
+
+	schedule, err := csv.NewReader(strings.NewReader(tables[2])).ReadAll()
+	assert.Nil(err, "Expected valid csv")
+	assert.Equal(37, len(schedule), "Expected a header and a row per payment")
+	assert.Equal([]string{"1", "2015-02-15"}, schedule[1][:2])
+}
+
+func TestQuoteEncodeCSVWithFees(t *testing.T) {
+	assert := assert.New(t)
+	q := newFeeQuote(t, FeeRules{Arrangement: 5000, Servicing: 150})
+
+	var buf bytes.Buffer
+	err := q.Encode(&buf, FormatCSV, false)
+	assert.Nil(err, "Expected the quote to encode")
+
+	tables := strings.Split(buf.String(), "\n\n")
+	assert.Equal(3, len(tables), "Expected quote, allocation and fee tables")
+
+	fees, err := csv.NewReader(strings.NewReader(tables[2])).ReadAll()
+	assert.Nil(err, "Expected valid csv")
+	assert.Equal([][]string{
+		feeCSVHeader,
+		{"arrangement", "upfront", "50.00", "50.00"},
+		{"servicing", "monthly", "1.50", "54.00"},
+	}, fees)
+}
+
+func TestQuoteEncodeText(t *testing.T) {
+	q := newTestQuote(t)
+
+	var buf bytes.Buffer
+	err := q.Encode(&buf, FormatText, false)
+
+	assert.Nil(t, err, "Expected the quote to encode")
+	assert.True(t, strings.HasPrefix(buf.String(), q.String()), "Expected the quote to be first")
+	assert.Contains(t, buf.String(), q.Allocations.String(), "Expected the allocations")
+}
+
+func TestComparisonEncodeCSV(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1200})
+
+	c, err := Compare(QuoteRequest{Amount: 1200, Lenders: lenders})
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	var buf bytes.Buffer
+	err = c.Encode(&buf, FormatCSV)
+	assert.Nil(t, err, "Expected the comparison to encode")
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.Nil(t, err, "Expected valid csv")
+	assert.Equal(t, 6, len(records), "Expected a header and a row per term")
+	assert.Equal(t, "12", records[1][2])
+}
@@ -0,0 +1,157 @@
+package quote
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/eazynow/goquote/lender"
+)
+
+// Policy represents the business rules that a quote must satisfy. Policies
+// allow the rules to be changed without a code release, and can be loaded
+// from a JSON or YAML file using the LoadPolicy function.
+type Policy struct {
+	// Version identifies the rules, so that it is possible to prove later
+	// which rules a quote was calculated with.
+	Version string `json:"version" yaml:"version"`
+	// MinAmount is the minimum amount allowed for a quote.
+	MinAmount int `json:"min_amount" yaml:"min_amount"`
+	// MaxAmount is the maximum amount allowed for a quote.
+	MaxAmount int `json:"max_amount" yaml:"max_amount"`
+	// Step is the amount that a quote must be a multiple of. Zero means any
+	// amount is allowed.
+	Step int `json:"step" yaml:"step"`
+	// Terms is the list of loan terms in months that can be quoted for. An
+	// empty list means any term of at least one month is allowed.
+	Terms []int `json:"terms" yaml:"terms"`
+	// MaxLenders is the maximum number of lenders that can fund a single loan.
+	// Zero means there is no limit.
+	MaxLenders int `json:"max_lenders" yaml:"max_lenders"`
+	// MaxLenderShare is the largest share of a loan, as a fraction between 0
+	// and 1, that can be funded by a single lender. Zero means there is no
+	// limit.
+	MaxLenderShare float64 `json:"max_lender_share" yaml:"max_lender_share"`
+	// Fees are the fees charged on every loan.
+	Fees FeeRules `json:"fees" yaml:"fees"`
+	// Market are the rules the lenders of a market are checked against when
+	// goquote imports it, using lender.Validate.
+	Market lender.Rules `json:"market" yaml:"market"`
+}
+
+// DefaultPolicyVersion is the Version of the DefaultPolicy.
+const DefaultPolicyVersion = "default"
+
+// DefaultPolicy returns the policy used when none is provided with a quote.
+func DefaultPolicy() Policy {
+	return Policy{
+		Version:   DefaultPolicyVersion,
+		MinAmount: MinAmount,
+		MaxAmount: MaxAmount,
+		Step:      AmountStep,
+		Terms:     []int{12, 24, 36, 48, 60},
+		Market:    lender.DefaultRules(),
+	}
+}
+
+// LoadPolicy is a function used to load a policy from the file located by
+// filename. Files with a .yaml or .yml extension are parsed as YAML, and all
+// others are parsed as JSON. Any rule missing from the file keeps the value
+// from the DefaultPolicy. If the file does not set a version, the sha256 hash
+// of the file is used as the version. The rules are checked once loaded.
+// Returns the loaded Policy if successful, or the associated error otherwise.
+func LoadPolicy(filename string) (Policy, error) {
+	policy := DefaultPolicy()
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return policy, err
+	}
+
+	// The version must come from the file, not the defaults.
+	policy.Version = ""
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &policy)
+	default:
+		err = json.Unmarshal(data, &policy)
+	}
+	if err != nil {
+		return policy, err
+	}
+
+	if policy.Version == "" {
+		policy.Version = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	}
+
+	return policy, policy.validate()
+}
+
+// validate is a private function that checks the rules of the policy make
+// sense, so that a mistyped policy cannot produce nonsense quotes.
+// Returns an error describing the first bad rule, or nil if there are none.
+func (p Policy) validate() error {
+	if p.MinAmount <= 0 {
+		return fmt.Errorf("The minimum amount of %d must be greater than zero", p.MinAmount)
+	}
+	if p.MinAmount > p.MaxAmount {
+		return fmt.Errorf("The minimum amount of %d is greater than the maximum amount of %d", p.MinAmount, p.MaxAmount)
+	}
+	if p.Step < 0 {
+		return fmt.Errorf("The step of %d cannot be negative", p.Step)
+	}
+	if p.MaxLenders < 0 {
+		return fmt.Errorf("The maximum number of lenders of %d cannot be negative", p.MaxLenders)
+	}
+	if p.MaxLenderShare < 0 || p.MaxLenderShare > 1 {
+		return fmt.Errorf("The maximum lender share of %g is not between 0 and 1", p.MaxLenderShare)
+	}
+	for _, term := range p.Terms {
+		if term <= 0 {
+			return fmt.Errorf("The term of %d months must be greater than zero", term)
+		}
+	}
+
+	return p.Fees.validate()
+}
+
+// AllowsTerm is a function used to check whether a loan term in months can be
+// quoted for under the policy. Only terms of at least one month are ever
+// allowed.
+// Returns true if the term is allowed.
+func (p Policy) AllowsTerm(term int) bool {
+	if term <= 0 {
+		return false
+	}
+	if len(p.Terms) == 0 {
+		return true
+	}
+
+	for _, t := range p.Terms {
+		if t == term {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LenderLimit is a function used to determine the most that can be drawn
+// from a single lender for a loan of the given amount.
+// Returns the maximum amount for a single lender.
+func (p Policy) LenderLimit(amount int) int {
+	if p.MaxLenderShare <= 0 || p.MaxLenderShare >= 1 {
+		return amount
+	}
+
+	// Allow for floating point error so that, for example, 29% of 1000 is 290
+	// rather than 289.
+	return int(math.Floor(float64(amount)*p.MaxLenderShare + 1e-9))
+}
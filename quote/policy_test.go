@@ -0,0 +1,173 @@
+package quote
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPolicyFailsOnNotFound(t *testing.T) {
+	_, err := LoadPolicy("unknownfile.json")
+
+	assert.NotNil(t, err, "Expected an error as the policy file cannot be found")
+}
+
+func TestLoadPolicyWorksWithJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := LoadPolicy("test_policy.json")
+
+	assert.Nil(err, "Expected file to work but it failed")
+	assert.Equal(500, policy.MinAmount)
+	assert.Equal(25000, policy.MaxAmount)
+	assert.Equal(50, policy.Step)
+	assert.Equal([]int{12, 24, 36}, policy.Terms)
+	assert.Equal(10, policy.MaxLenders)
+	assert.Equal(0.25, policy.MaxLenderShare)
+	assert.Equal(lender.DefaultRules(), policy.Market, "Expected the default market rules")
+}
+
+func TestLoadPolicyWorksWithYAMLAndKeepsDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := LoadPolicy("test_policy.yaml")
+
+	assert.Nil(err, "Expected file to work but it failed")
+	assert.Equal(2000, policy.MinAmount)
+	assert.Equal(MaxAmount, policy.MaxAmount, "Missing rules should keep the default")
+	assert.Equal(AmountStep, policy.Step, "Missing rules should keep the default")
+	assert.Equal([]int{36, 60}, policy.Terms)
+	assert.Equal(5, policy.MaxLenders)
+	assert.Equal(0.5, policy.Market.MaxRate)
+	assert.Equal(1, policy.Market.MinAvailable, "Missing market rules should keep the default")
+	assert.True(policy.Market.UniqueNames, "Missing market rules should keep the default")
+}
+
+func TestPolicyAllowsTerm(t *testing.T) {
+	policy := Policy{Terms: []int{12, 36}}
+
+	assert.True(t, policy.AllowsTerm(36), "36 months is in the list of terms")
+	assert.False(t, policy.AllowsTerm(24), "24 months is not in the list of terms")
+	assert.True(t, Policy{}.AllowsTerm(24), "Any term is allowed when there is no list")
+	assert.False(t, Policy{}.AllowsTerm(0), "A term must be at least one month")
+	assert.False(t, Policy{}.AllowsTerm(-1), "A term must be at least one month")
+}
+
+func TestLoadPolicyRejectsBadRules(t *testing.T) {
+	for _, policy := range []string{
+		`{"min_amount": 0}`,
+		`{"min_amount": -100}`,
+		`{"min_amount": 2000, "max_amount": 1000}`,
+		`{"step": -50}`,
+		`{"max_lenders": -1}`,
+		`{"max_lender_share": 1.5}`,
+		`{"max_lender_share": -0.1}`,
+		`{"terms": [12, 0]}`,
+		`{"terms": [-12]}`,
+		`{"fees": {"origination": 2}}`,
+	} {
+		filename := filepath.Join(t.TempDir(), "policy.json")
+		if err := os.WriteFile(filename, []byte(policy), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadPolicy(filename)
+		assert.NotNil(t, err, "Expected %s to be rejected", policy)
+	}
+}
+
+func TestQuoteValidateFailsOnTermsBelowOneMonth(t *testing.T) {
+	for _, term := range []int{0, -1} {
+		q := Quote{RequestedAmount: 1000, Term: term}
+		q.policy = &Policy{MinAmount: 500, MaxAmount: 5000}
+
+		err := q.validate()
+
+		assert.True(t, errors.Is(err, ErrInvalidTerm), "Expected a term of %d months to be rejected", term)
+	}
+}
+
+func TestQuoteValidateFailsOnBadPolicy(t *testing.T) {
+	q := Quote{RequestedAmount: 1000, Term: 12}
+	q.policy = &Policy{MaxAmount: 5000}
+
+	assert.NotNil(t, q.validate(), "Expected a policy without a minimum amount to be rejected")
+}
+
+func TestPolicyLenderLimit(t *testing.T) {
+	assert.Equal(t, 1000, Policy{}.LenderLimit(1000), "No share set means no limit")
+	assert.Equal(t, 290, Policy{MaxLenderShare: 0.29}.LenderLimit(1000))
+}
+
+func TestQuoteValidateUsesPolicy(t *testing.T) {
+	q := Quote{}
+	q.policy = &Policy{MinAmount: 500, MaxAmount: 800, Step: 50, Terms: []int{12}}
+
+	q.RequestedAmount = 650
+	q.Term = 12
+	assert.Nil(t, q.validate(), "Expected amount to be valid for the policy")
+
+	q.RequestedAmount = 450
+	assert.NotNil(t, q.validate(), "Expected an error as requested amount was too low")
+
+	q.RequestedAmount = 850
+	assert.NotNil(t, q.validate(), "Expected an error as requested amount was too high")
+
+	q.RequestedAmount = 660
+	assert.NotNil(t, q.validate(), "Expected an error as requested amount was not a multiple of 50")
+
+	q.RequestedAmount = 650
+	q.Term = 36
+	assert.NotNil(t, q.validate(), "Expected an error as the term is not allowed")
+}
+
+func TestQuoteCalculateRespectsMaxLenders(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.05, Available: 600})
+	lenders = append(lenders, lender.Lender{Rate: 0.06, Available: 600})
+	lenders = append(lenders, lender.Lender{Rate: 0.07, Available: 600})
+
+	q := Quote{}
+	q.RequestedAmount = 1500
+	q.lenders = lenders
+	q.Term = 36
+	q.policy = &Policy{MaxLenders: 2}
+
+	err := q.calculate()
+
+	assert.NotNil(t, err, "Expected an error as the loan needs three lenders")
+}
+
+func TestQuoteCalculateRespectsMaxLenderShare(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.05, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.06, Available: 1000})
+
+	quote, err := Calculate(QuoteRequest{
+		Amount:  1000,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{Policy: &Policy{MinAmount: 1000, MaxAmount: 1000, MaxLenderShare: 0.6}},
+	})
+
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+	assert.Equal(2, len(quote.Allocations), "Expected the loan to be split")
+	assert.Equal(600, quote.Allocations[0].Amount, "Jane can only fund 60% of the loan")
+	assert.Equal(400, quote.Allocations[1].Amount, "Bob funds the remainder")
+}
+
+func TestLoadPolicyVersion(t *testing.T) {
+	policy, err := LoadPolicy("test_policy.json")
+	assert.Nil(t, err, "Expected file to work but it failed")
+	assert.Equal(t, "2015-01", policy.Version)
+
+	policy, err = LoadPolicy("test_policy.yaml")
+	assert.Nil(t, err, "Expected file to work but it failed")
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", policy.Version, "Expected the file hash when no version is set")
+}
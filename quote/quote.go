@@ -2,67 +2,131 @@
 package quote
 
 import (
-	"errors"
 	"fmt"
 	"math"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
 )
 
-// Store these as consts for this exercise, however for more flexibility
-// these could be passed in also as configuration for a quote
+// The amount limits are the defaults used by DefaultPolicy. They can be
+// overridden for a quote by passing a Policy in the quote Options.
 const (
-	// MinAmount is the minimum amount allowed for a quote. It is checked by the
-	// quote.validate function.
+	// MinAmount is the default minimum amount allowed for a quote. It is checked
+	// by the quote.validate function.
 	MinAmount = 1000
-	// MaxAmount is the maximum amount allowed for a quote. It is checked by the
-	// quote.validate function.
+	// MaxAmount is the default maximum amount allowed for a quote. It is checked
+	// by the quote.validate function.
 	MaxAmount = 15000
+	// AmountStep is the default amount that a quote must be a multiple of. It
+	// is checked by the quote.validate function.
+	AmountStep = 100
+	// DefaultTerm is the loan length in months used when none is requested.
+	DefaultTerm = 36
 	// compoundFrequency defines the number of periods that interest is compounded
 	// to in a year
 	compoundFrequency = 12.0
 )
 
-// quote represents the structure for a quote response. It is private and needs
-// to be creeated using the NewQuote function.
-type quote struct {
-	lenders          lender.Lenders
-	loanPeriodMonths int
-	RequestedAmount  int
-	Rate             float64
-	MonthlyRepayment float64
-	TotalRepayment   float64
+// Quote represents the structure for a quote response. It needs to be created
+// using the Calculate or NewQuote functions. Every quote has a unique ID, and
+// records the Version of the policy and the Hash of the lenders it was
+// calculated from so that the offer can be reproduced later. The FundedAmount
+// is the amount the quote is for, which is less than the RequestedAmount by
+// the Shortfall when a partial quote is allowed and the market cannot fund the
+// whole request. The AmountReceived is the amount paid to the borrower once
+// any upfront fees have been deducted, and the TotalCostOfCredit is the total
+// repayment less the amount received.
+type Quote struct {
+	lenders           lender.Lenders
+	policy            *Policy
+	rounding          money.RoundingMode
+	strategy          Strategy
+	partial           bool
+	payments          Schedule
+	financed          money.Amount
+	ID                string       `json:"id" yaml:"id"`
+	CreatedAt         time.Time    `json:"created_at" yaml:"created_at"`
+	ExpiresAt         time.Time    `json:"expires_at" yaml:"expires_at"`
+	PolicyVersion     string       `json:"policy_version" yaml:"policy_version"`
+	MarketHash        string       `json:"market_hash" yaml:"market_hash"`
+	RequestedAmount   int          `json:"requested_amount" yaml:"requested_amount"`
+	FundedAmount      int          `json:"funded_amount" yaml:"funded_amount"`
+	Shortfall         int          `json:"shortfall" yaml:"shortfall"`
+	Currency          string       `json:"currency" yaml:"currency"`
+	Term              int          `json:"term" yaml:"term"`
+	StartDate         time.Time    `json:"start_date" yaml:"start_date"`
+	Rate              float64      `json:"rate" yaml:"rate"`
+	APR               float64      `json:"apr" yaml:"apr"`
+	MonthlyRepayment  money.Amount `json:"monthly_repayment" yaml:"monthly_repayment"`
+	TotalRepayment    money.Amount `json:"total_repayment" yaml:"total_repayment"`
+	AmountReceived    money.Amount `json:"amount_received" yaml:"amount_received"`
+	TotalCostOfCredit money.Amount `json:"total_cost_of_credit" yaml:"total_cost_of_credit"`
+	Fees              []Fee        `json:"fees,omitempty" yaml:"fees,omitempty"`
+	Allocations       Allocations  `json:"allocations" yaml:"allocations"`
 }
 
-// validate is a private function that validates the quote request criteria for
-// preset rules.
+// rules is a private function that returns the policy the quote is checked
+// against.
+// Returns the policy passed in the quote options, or the DefaultPolicy if none
+// was provided.
+func (q *Quote) rules() Policy {
+	if q.policy == nil {
+		return DefaultPolicy()
+	}
+	return *q.policy
+}
+
+// allocator is a private function that returns the strategy used to share
+// the loan between the lenders.
+// Returns the strategy passed in the quote options, or CheapestFirst if none
+// was provided.
+func (q *Quote) allocator() Strategy {
+	if q.strategy == nil {
+		return CheapestFirst{}
+	}
+	return q.strategy
+}
+
+// currency is a private function that returns the currency of the quote.
+// Returns the Currency, or the money.DefaultCurrency if none is set.
+func (q *Quote) currency() money.Currency {
+	return money.CurrencyOrDefault(q.Currency)
+}
+
+// validate is a private function that validates the quote request criteria
+// against the rules of the quote policy.
 // It returns an error detailing the validation failure if one is found. If
 // there are no errors then nil is returned.
-func (q *quote) validate() error {
+func (q *Quote) validate() error {
+	policy := q.rules()
+
+	// Check the rules themselves make sense, as a policy can be built in code
+	// without being loaded.
+	if err := policy.validate(); err != nil {
+		return err
+	}
 
 	// Check the quote rate is greater than the minimum amount and reject if not.
-	if q.RequestedAmount < MinAmount {
-		return errors.New(
-			fmt.Sprintf(
-				"Loan amount of £%d is too low. Minimum loan amount is £%d",
-				q.RequestedAmount,
-				MinAmount))
+	if q.RequestedAmount < policy.MinAmount {
+		return NewAmountError(ErrAmountTooLow, q.RequestedAmount, policy.MinAmount, q.Currency)
 	}
 
 	// Check the quote rate is less than the maximum amount and reject if not.
-	if q.RequestedAmount > MaxAmount {
-		return errors.New(
-			fmt.Sprintf(
-				"Loan amount of £%d is too high. Maximum loan amount is £%d",
-				q.RequestedAmount,
-				MaxAmount))
+	if q.RequestedAmount > policy.MaxAmount {
+		return NewAmountError(ErrAmountTooHigh, q.RequestedAmount, policy.MaxAmount, q.Currency)
 	}
 
-	// Check that the amount is a multiple of 100 and reject if not.
-	if q.RequestedAmount%100 != 0 {
-		return errors.New("Loan amount must be a multiple of £100")
+	// Check that the amount is a multiple of the step and reject if not.
+	if policy.Step > 0 && q.RequestedAmount%policy.Step != 0 {
+		return NewAmountError(ErrAmountStep, q.RequestedAmount, policy.Step, q.Currency)
+	}
+
+	// Check that the loan period is one of the allowed terms and reject if not.
+	if !policy.AllowsTerm(q.Term) {
+		return &TermError{Term: q.Term, Allowed: policy.Terms}
 	}
 
 	return nil
@@ -73,79 +137,146 @@ func (q *quote) validate() error {
 // Results are populated into the quote structure.
 // Returns any error found when attempting to calculate the quote. If there are
 // no errors then nil is returned.
-func (q *quote) calculate() error {
-	// sort lenders into order of preference ascending order (based on rate)
-	sort.Sort(q.lenders)
-
-	balance := q.RequestedAmount
-
-	// convert the load period into a float ready for rate calculation.
-	// You cannot mix integers and floats in computation.
-	fPeriod := float64(q.loanPeriodMonths)
-
-	blendedRate := 0.0
+func (q *Quote) calculate() error {
+	policy := q.rules()
+
+	// Share the loan between the lenders that will lend over the term, using
+	// the requested strategy.
+	lenders := q.lenders.ForTerm(q.Term)
+	strategy := q.allocator()
+	q.FundedAmount = q.RequestedAmount
+	q.Allocations = strategy.Allocate(q.FundedAmount, lenders, policy)
+
+	if err := q.fund(strategy, lenders); err != nil {
+		return err
+	}
 
-	// Loop through the sorted lender list.
-	for _, l := range q.lenders {
+	if err := q.price(); err != nil {
+		return err
+	}
 
-		// Find out how much we can borrow from this lender.
-		amount := l.Borrow(balance)
-		fAmount := float64(amount)
+	// No errors so quote has been calculated!
+	return nil
+}
 
-		// Calculate the monthly repayment for the lender.
-		q.MonthlyRepayment += calculateMonthlyRate(l.Rate, fPeriod, fAmount)
+// fund is a private function that checks the allocations fund the requested
+// amount. If they do not and partial quotes are allowed, the lenders are
+// allocated as much as they can fund instead.
+// Returns an InsufficientFundsError if the loan cannot be funded, or nil
+// otherwise.
+func (q *Quote) fund(strategy Strategy, lenders lender.Lenders) error {
+	// Outstanding balance means there was insufficient funds available from the pool
+	// of lenders. If partial quotes are allowed, quote for as much as can be
+	// funded instead.
+	if shortfall := q.RequestedAmount - q.Allocations.Total(); shortfall > 0 {
+		if q.partial {
+			q.FundedAmount, q.Allocations = fundPartial(strategy, lenders, q.rules(), q.Allocations.Total())
+		}
+		if q.FundedAmount == 0 || !q.partial {
+			return &InsufficientFundsError{
+				Requested: q.RequestedAmount,
+				Shortfall: shortfall,
+			}
+		}
+		q.Shortfall = q.RequestedAmount - q.FundedAmount
+	}
 
-		blendedRate += fAmount * l.Rate
+	return nil
+}
 
-		balance -= amount
+// price is a private function that works out the rate, fees, repayments and
+// APR of the quote once the loan has been funded.
+// Results are populated into the quote structure.
+// Returns an error if the repayments cannot be worked out, or nil otherwise.
+func (q *Quote) price() error {
+	policy := q.rules()
 
-		if balance == 0 {
-			break
-		}
+	blendedRate := 0.0
+	for _, a := range q.Allocations {
+		blendedRate += float64(a.Amount) * a.Rate
 	}
 
-	// Outstanding balance means there was insufficient funds available from the pool
-	// of lenders.
-	if balance > 0 {
-		return errors.New("It is not possible to provide a quote at this time.")
+	// Work out the fees. Upfront fees reduce the amount the borrower receives,
+	// and financed fees are repaid at the blended rate with the loan.
+	var upfront money.Amount
+	q.Fees, upfront, q.financed = policy.Fees.charges(q.FundedAmount, q.Term, q.rounding)
+	if upfront >= money.FromMajor(q.FundedAmount) {
+		return &FeeError{Upfront: upfront, Amount: q.FundedAmount, Currency: q.Currency}
 	}
+	q.AmountReceived = money.FromMajor(q.FundedAmount) - upfront
 
 	// Calculate final values for the quote
-	q.TotalRepayment = fPeriod * q.MonthlyRepayment
-	q.Rate = blendedRate / float64(q.RequestedAmount)
+	q.Rate = blendedRate / float64(q.FundedAmount)
+	if err := q.amortize(); err != nil {
+		return err
+	}
+	q.TotalCostOfCredit = q.TotalRepayment - q.AmountReceived
 
-	// No errors so quote has been calculated!
+	// The APR is based on the actual cash flows of the loan, so includes all
+	// of the fees.
+	payments := make([]float64, len(q.payments))
+	for i, p := range q.payments {
+		payments[i] = p.Amount.Float()
+	}
+	q.APR = calculateAPR(q.AmountReceived.Float(), payments)
 	return nil
 }
 
 // String is a public function to return a string represenatation of a quote results.
 // Used to satisfy the fmt.Stringer interface.
 // Returns a string representing the quote results.
-func (q *quote) String() string {
+func (q *Quote) String() string {
+
+	currency := q.currency()
 
 	// Build a slice up containing the return format.
 	var s []string
-	s = append(s, fmt.Sprintf("Requested amount: £%d", q.RequestedAmount))
+	s = append(s, fmt.Sprintf("Requested amount: %s", currency.FormatMajor(q.RequestedAmount)))
+	if q.Shortfall > 0 {
+		s = append(s, fmt.Sprintf("We can lend you %s of the %s requested",
+			currency.FormatMajor(q.FundedAmount),
+			currency.FormatMajor(q.RequestedAmount)))
+	}
 	s = append(s, fmt.Sprintf("Rate: %.1f%%", q.Rate*100.0))
-	s = append(s, fmt.Sprintf("Monthly repayment: £%.2f", q.MonthlyRepayment))
-	s = append(s, fmt.Sprintf("Total repayment: £%.2f", q.TotalRepayment))
+	s = append(s, fmt.Sprintf("APR: %.1f%%", q.APR*100.0))
+	s = append(s, fmt.Sprintf("Monthly repayment: %s", currency.Format(q.MonthlyRepayment)))
+	s = append(s, fmt.Sprintf("Total repayment: %s", currency.Format(q.TotalRepayment)))
+
+	// Itemise the fees, if any were charged.
+	if len(q.Fees) > 0 {
+		for _, f := range q.Fees {
+			s = append(s, f.Format(currency))
+		}
+		s = append(s, fmt.Sprintf("Amount received: %s", currency.Format(q.AmountReceived)))
+		s = append(s, fmt.Sprintf("Total cost of credit: %s", currency.Format(q.TotalCostOfCredit)))
+	}
 
 	// Return the string, separated by line breaks.
 	return strings.Join(s, "\n")
 }
 
-// NewQuote is the main function used to generate a quote based on the amount
-// provided, the loanPeriod in months and a slice of lenders.
-// The request is quote request is first validated against business rules
-// and then calculated.
-// Returns a pointer to a quote structure with the quote details if successful.
+// Calculate is the main function used to generate a quote based on a
+// QuoteRequest.
+// The quote request is first validated against business rules and then
+// calculated.
+// The lenders in the request are never modified, so it is safe to calculate
+// several quotes at the same time against a shared market, as long as the
+// market is not changed while they are being calculated.
+// Returns a pointer to a Quote structure with the quote details if successful.
 // If unsuccesful due to validation or calculation issues then an error is returned.
-func NewQuote(amount, loanPeriod int, lenders lender.Lenders) (*quote, error) {
-
-	quote := quote{
-		RequestedAmount:  amount,
-		lenders:          lenders,
-		loanPeriodMonths: loanPeriod,
+func Calculate(req QuoteRequest) (*Quote, error) {
+	quote := newQuote(req)
+	quote.lenders = req.Lenders
+	quote.MarketHash = req.Lenders.Hash()
+
+	// All of the lenders must lend in the same currency, and that must be the
+	// currency that was requested.
+	currency, err := req.Lenders.Currency()
+	if err != nil {
+		return nil, err
+	}
+	if err := quote.setCurrency(req.Currency, currency); err != nil {
+		return nil, err
 	}
 
 	// Attempt to validate the quote input variables. If validation fails an erroe
@@ -160,7 +291,109 @@ func NewQuote(amount, loanPeriod int, lenders lender.Lenders) (*quote, error) {
 		return nil, err
 	}
 
-	return &quote, nil
+	return quote.issue()
+}
+
+// newQuote is a private function that starts a quote for the request, with
+// the options applied and the quote dated, but without the lenders.
+// Returns the Quote.
+func newQuote(req QuoteRequest) Quote {
+	quote := Quote{
+		RequestedAmount: req.Amount,
+		Term:            req.Term,
+		policy:          req.Options.Policy,
+		rounding:        req.Options.Rounding,
+		strategy:        req.Options.Strategy,
+		partial:         req.Options.AllowPartial,
+		StartDate:       req.Options.StartDate,
+		CreatedAt:       req.Options.CreatedAt,
+	}
+	quote.PolicyVersion = quote.rules().Version
+
+	// Date the quote from now if no creation time or start date was
+	// requested.
+	if quote.CreatedAt.IsZero() {
+		quote.CreatedAt = time.Now()
+	}
+	if quote.StartDate.IsZero() {
+		quote.StartDate = quote.CreatedAt
+	}
+
+	validity := req.Options.ValidFor
+	if validity <= 0 {
+		validity = DefaultValidity
+	}
+	quote.ExpiresAt = quote.CreatedAt.Add(validity)
+
+	return quote
+}
+
+// setCurrency is a private function that sets the currency of the quote to
+// the currency of the market, checking it is the currency that was requested
+// if there was one.
+// Returns a CurrencyError if the currencies do not match, or nil otherwise.
+func (q *Quote) setCurrency(requested, market string) error {
+	if requested != "" {
+		c, err := money.LookupCurrency(requested)
+		if err != nil {
+			return err
+		}
+		if c.Code != market {
+			return &CurrencyError{Requested: c.Code, Market: market}
+		}
+	}
+
+	q.Currency = market
+	return nil
+}
+
+// issue is a private function that gives a calculated quote its unique ID.
+// Returns a pointer to the Quote, or the error creating the ID.
+func (q *Quote) issue() (*Quote, error) {
+	id, err := NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	q.ID = id
+	return q, nil
+}
+
+// fundPartial is a private function used to find the largest amount that the
+// lenders can fund when they cannot fund the whole request. The amount is no
+// more than what is available, rounded down to a multiple of the policy step,
+// and no less than the policy minimum.
+// Returns the amount and its allocations, or zero if not even the policy
+// minimum can be funded.
+func fundPartial(strategy Strategy, lenders lender.Lenders, policy Policy, available int) (int, Allocations) {
+	step := policy.Step
+	if step <= 0 {
+		step = 1
+	}
+
+	// Some strategies cannot draw everything that is available, so keep
+	// stepping down until the lenders can fund the amount in full.
+	for amount := available - available%step; amount > 0 && amount >= policy.MinAmount; amount -= step {
+		allocations := strategy.Allocate(amount, lenders, policy)
+		if allocations.Total() == amount {
+			return amount, allocations
+		}
+	}
+
+	return 0, nil
+}
+
+// NewQuote is a helper function used to generate a quote based on the amount
+// provided, the loanPeriod in months and a slice of lenders, using the default
+// options. It is kept for compatibility and wraps the Calculate function.
+// Returns a pointer to a Quote structure with the quote details if successful.
+// If unsuccesful due to validation or calculation issues then an error is returned.
+func NewQuote(amount, loanPeriod int, lenders lender.Lenders) (*Quote, error) {
+	return Calculate(QuoteRequest{
+		Amount:  amount,
+		Term:    loanPeriod,
+		Lenders: lenders,
+	})
 }
 
 // calculateMonthlyRate contains the formula for calculating a compound interest monthly
@@ -172,6 +405,12 @@ func calculateMonthlyRate(annualRate, loanPeriod, amount float64) float64 {
 
 	compoundRate := annualRate / compoundFrequency
 
+	// Without interest the formula divides zero by zero, and the amount is
+	// simply repaid in equal parts.
+	if compoundRate == 0 {
+		return amount / loanPeriod
+	}
+
 	// Apply the formula and return the monthly rate.
 	return amount * (compoundRate *
 		math.Pow((1.0+compoundRate), loanPeriod)) / (math.Pow(1.0+compoundRate, loanPeriod) - 1)
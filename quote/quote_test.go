@@ -1,16 +1,20 @@
 package quote
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestQuoteValidateFailsOnLowAmount(t *testing.T) {
-	q := quote{}
+	q := Quote{}
 
 	// use the constant set so if it changes, the test adapts
 	q.RequestedAmount = MinAmount - 1
@@ -22,7 +26,7 @@ func TestQuoteValidateFailsOnLowAmount(t *testing.T) {
 }
 
 func TestQuoteValidateFailsOnHighAmount(t *testing.T) {
-	q := quote{}
+	q := Quote{}
 
 	// use the constant set so if it changes, the test adapts
 	q.RequestedAmount = MaxAmount + 1
@@ -33,7 +37,7 @@ func TestQuoteValidateFailsOnHighAmount(t *testing.T) {
 }
 
 func TestQuoteValidateFailsOnMultiple(t *testing.T) {
-	q := quote{}
+	q := Quote{}
 
 	// use the constant set so if it changes, the test adapts
 	q.RequestedAmount = MinAmount + 10
@@ -44,10 +48,11 @@ func TestQuoteValidateFailsOnMultiple(t *testing.T) {
 }
 
 func TestQuoteValidateWorksOnGoodValues(t *testing.T) {
-	q := quote{}
+	q := Quote{}
 
 	// use the constant set so if it changes, the test adapts
 	q.RequestedAmount = MinAmount + 100
+	q.Term = 36
 
 	err := q.validate()
 
@@ -59,16 +64,16 @@ func TestQuoteCalculateFailsWhenAmountNotAvailable(t *testing.T) {
 
 	// only 1200 available in total
 	var lenders lender.Lenders
-	l1 := lender.Lender{"", 0.01, 1000}
-	l2 := lender.Lender{"", 0.01, 200}
+	l1 := lender.Lender{Rate: 0.01, Available: 1000}
+	l2 := lender.Lender{Rate: 0.01, Available: 200}
 
 	lenders = append(lenders, l1)
 	lenders = append(lenders, l2)
 
-	q := quote{}
+	q := Quote{}
 	q.RequestedAmount = amount
 	q.lenders = lenders
-	q.loanPeriodMonths = 36
+	q.Term = 36
 
 	err := q.calculate()
 
@@ -80,16 +85,16 @@ func TestQuoteCalculateWorksWithCorrectValuesMatchingRates(t *testing.T) {
 	amount := 1100
 
 	var lenders lender.Lenders
-	l1 := lender.Lender{"", 0.051, 1000}
-	l2 := lender.Lender{"", 0.051, 200}
+	l1 := lender.Lender{Rate: 0.051, Available: 1000}
+	l2 := lender.Lender{Rate: 0.051, Available: 200}
 
 	lenders = append(lenders, l1)
 	lenders = append(lenders, l2)
 
-	q := quote{}
+	q := Quote{}
 	q.RequestedAmount = amount
 	q.lenders = lenders
-	q.loanPeriodMonths = 36
+	q.Term = 36
 
 	err := q.calculate()
 
@@ -105,19 +110,21 @@ func TestQuoteCalculateWorksWithCorrectValuesMatchingRates(t *testing.T) {
 	// l2 = 100 * ((0.051/12)*(1+(0.051/12))^36)/((1+(0.051/12))^36-1)
 	// l2 = 3.0015815509
 	// total monthly repayment = 33.0173970599
-	assert.Equal("33.02", fmt.Sprintf("%.2f", q.MonthlyRepayment), "Expected total payment to be £33.02")
+	assert.Equal("33.02", q.MonthlyRepayment.String(), "Expected total payment to be £33.02")
 
-	// total repayment = 33.0173970599 * 36
-	// total repayment = 1188.6262942
-	assert.Equal("1188.63", fmt.Sprintf("%.2f", q.TotalRepayment), "Expected total payment to be £1188.63")
+	// total repayment = sum of every payment in the schedule, with interest
+	// rounded to the penny each month and the final payment adjusted to clear
+	// the balance
+	// total repayment = 1188.62
+	assert.Equal("1188.62", q.TotalRepayment.String(), "Expected total payment to be £1188.62")
 }
 
 func TestNewQuoteCallsValidateAndFails(t *testing.T) {
 	amount := MinAmount - 1
 
 	var lenders lender.Lenders
-	l1 := lender.Lender{"", 0.05, 1000}
-	l2 := lender.Lender{"", 0.05, 200}
+	l1 := lender.Lender{Rate: 0.05, Available: 1000}
+	l2 := lender.Lender{Rate: 0.05, Available: 200}
 
 	lenders = append(lenders, l1)
 	lenders = append(lenders, l2)
@@ -133,8 +140,8 @@ func TestNewQuoteCallsCalculateAndFailsWhenAmountNotAvailable(t *testing.T) {
 
 	// only 1200 available in total
 	var lenders lender.Lenders
-	l1 := lender.Lender{"", 0.01, 1000}
-	l2 := lender.Lender{"", 0.01, 200}
+	l1 := lender.Lender{Rate: 0.01, Available: 1000}
+	l2 := lender.Lender{Rate: 0.01, Available: 200}
 
 	lenders = append(lenders, l1)
 	lenders = append(lenders, l2)
@@ -150,8 +157,8 @@ func TestNewQuoteCallsCalculateAndWorks(t *testing.T) {
 	amount := 1200
 
 	var lenders lender.Lenders
-	l1 := lender.Lender{"", 0.051, 1000}
-	l2 := lender.Lender{"", 0.069, 200}
+	l1 := lender.Lender{Rate: 0.051, Available: 1000}
+	l2 := lender.Lender{Rate: 0.069, Available: 200}
 
 	lenders = append(lenders, l1)
 	lenders = append(lenders, l2)
@@ -171,28 +178,266 @@ func TestNewQuoteCallsCalculateAndWorks(t *testing.T) {
 	// l2 = 200 * ((0.069/12)*(1+(0.069/12))^36)/((1+(0.069/12))^36-1)
 	// l2 = 6.1662791696
 	// total monthly repayment = 36.1820946786
-	assert.Equal("36.18", fmt.Sprintf("%.2f", quote.MonthlyRepayment), "Expected total payment to be £36.18")
+	assert.Equal("36.18", quote.MonthlyRepayment.String(), "Expected total payment to be £36.18")
 
-	// total repayment = 36.1820946786 * 36
-	// total repayment = 1302.5554084
-	assert.Equal("1302.56", fmt.Sprintf("%.2f", quote.TotalRepayment), "Expected total payment to be £1302.56")
+	// total repayment = sum of every payment in the schedule, with interest
+	// rounded to the penny each month and the final payment adjusted to clear
+	// the balance
+	// total repayment = 1302.50
+	assert.Equal("1302.50", quote.TotalRepayment.String(), "Expected total payment to be £1302.50")
 }
 
 func TestQuoteStringReturnsCorrectFormat(t *testing.T) {
-	q := quote{}
+	q := Quote{}
 	q.RequestedAmount = 1200
-	q.MonthlyRepayment = 36.1820946786
-	q.TotalRepayment = 1302.5554084
+	q.MonthlyRepayment = 3618
+	q.TotalRepayment = 130256
 	q.Rate = 0.054
+	q.APR = 0.0554
 	resp := q.String()
 
 	assert.NotEmpty(t, resp, "Expected a string response")
 
 	lines := strings.Split(resp, "\n")
-	assert.Equal(t, 4, len(lines), "Expected 4 files in response")
+	assert.Equal(t, 5, len(lines), "Expected 5 files in response")
 	assert.Equal(t, "Requested amount: £1200", lines[0], "Amount line should match format")
 	assert.Equal(t, "Rate: 5.4%", lines[1], "Rate line should match format")
-	assert.Equal(t, "Monthly repayment: £36.18", lines[2], "Monthly line should match format")
-	assert.Equal(t, "Total repayment: £1302.56", lines[3], "Total line should match format")
+	assert.Equal(t, "APR: 5.5%", lines[2], "APR line should match format")
+	assert.Equal(t, "Monthly repayment: £36.18", lines[3], "Monthly line should match format")
+	assert.Equal(t, "Total repayment: £1302.56", lines[4], "Total line should match format")
 
 }
+
+func TestCalculateWorksWithRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	l1 := lender.Lender{Rate: 0.051, Available: 1000}
+	l2 := lender.Lender{Rate: 0.069, Available: 200}
+
+	lenders = append(lenders, l1)
+	lenders = append(lenders, l2)
+
+	start := time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	quote, err := Calculate(QuoteRequest{
+		Amount:  1200,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{StartDate: start},
+	})
+
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+	assert.NotNil(quote, "Expected a valid quote structure returned")
+
+	assert.Equal("0.054", fmt.Sprintf("%.3f", quote.Rate), "Expected rate to be 5.4%")
+	assert.Equal("36.18", quote.MonthlyRepayment.String(), "Expected total payment to be £36.18")
+	assert.Equal(start, quote.StartDate, "Expected the requested start date to be kept")
+}
+
+func TestCalculateWorksWithZeroRateLender(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Zed", Rate: 0, Available: 2000})
+
+	quote, err := Calculate(QuoteRequest{Amount: 1000, Term: 36, Lenders: lenders})
+
+	assert.Nil(err, "Expected a lender charging no interest to be quoted from")
+	assert.Equal(0.0, quote.Rate)
+	assert.Equal(0.0, quote.APR)
+	assert.Equal("27.78", quote.MonthlyRepayment.String(), "Expected the amount to be repaid in equal parts")
+	assert.Equal("1000.00", quote.TotalRepayment.String(), "Expected no interest to be repaid")
+	assert.Equal("0.00", quote.Allocations[0].TotalInterest.String())
+}
+
+func TestCalculateDefaultsStartDate(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1200})
+
+	quote, err := Calculate(QuoteRequest{Amount: 1200, Term: 36, Lenders: lenders})
+
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+	assert.False(t, quote.StartDate.IsZero(), "Expected the start date to default to now")
+}
+
+func TestCalculateUsesMarketCurrency(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jan", Rate: 0.051, Available: 1000, Currency: "EUR"})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200, Currency: "EUR"})
+
+	quote, err := Calculate(QuoteRequest{Amount: 1200, Term: 36, Lenders: lenders})
+
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+	assert.Equal("EUR", quote.Currency)
+
+	lines := strings.Split(quote.String(), "\n")
+	assert.Equal("Requested amount: €1200", lines[0], "Amount line should use the currency")
+	assert.Equal("Monthly repayment: €36,18", lines[3], "Monthly line should use the currency")
+}
+
+func TestCalculateFailsWithMixedCurrencies(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000, Currency: "EUR"})
+	lenders = append(lenders, lender.Lender{Rate: 0.069, Available: 200, Currency: "USD"})
+
+	_, err := Calculate(QuoteRequest{Amount: 1200, Term: 36, Lenders: lenders})
+
+	assert.Equal(t, lender.ErrMixedCurrencies, err, "Expected an error as the market mixes currencies")
+}
+
+func TestCalculateFailsWhenCurrencyDoesNotMatchMarket(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1200, Currency: "USD"})
+
+	_, err := Calculate(QuoteRequest{Amount: 1200, Currency: "eur", Term: 36, Lenders: lenders})
+
+	assert.True(t, errors.Is(err, ErrCurrencyMismatch), "Expected ErrCurrencyMismatch")
+	assert.Equal(t, "Loans in EUR are not available. The market lends in USD", err.Error())
+}
+
+func TestCalculateValidationUsesCurrency(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1200, Currency: "USD"})
+
+	_, err := Calculate(QuoteRequest{Amount: 900, Term: 36, Lenders: lenders})
+
+	assert.Equal(t, "Loan amount of $900 is too low. Minimum loan amount is $1000", err.Error())
+}
+
+func TestCalculatePartialQuote(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.05, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.06, Available: 950})
+
+	quote, err := Calculate(QuoteRequest{
+		Amount:  2000,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{AllowPartial: true},
+	})
+
+	assert.Nil(err, "Expected a partial quote")
+	assert.Equal(2000, quote.RequestedAmount)
+	assert.Equal(1900, quote.FundedAmount, "Expected the amount available rounded down to the step")
+	assert.Equal(100, quote.Shortfall)
+	assert.Equal(1900, quote.Allocations.Total())
+	assert.Equal(money.FromMajor(1900), quote.AmountReceived)
+
+	lines := strings.Split(quote.String(), "\n")
+	assert.Equal("Requested amount: £2000", lines[0])
+	assert.Equal("We can lend you £1900 of the £2000 requested", lines[1])
+}
+
+func TestCalculateFullQuoteWhenPartialAllowed(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.05, Available: 2000})
+
+	quote, err := Calculate(QuoteRequest{Amount: 2000, Term: 36, Lenders: lenders, Options: Options{AllowPartial: true}})
+
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+	assert.Equal(t, 2000, quote.FundedAmount)
+	assert.Equal(t, 0, quote.Shortfall)
+	assert.Equal(t, 5, len(strings.Split(quote.String(), "\n")), "Expected no partial line")
+}
+
+func TestCalculatePartialQuoteFailsBelowMinimum(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.05, Available: 900})
+
+	_, err := Calculate(QuoteRequest{Amount: 2000, Term: 36, Lenders: lenders, Options: Options{AllowPartial: true}})
+
+	var fundsErr *InsufficientFundsError
+	assert.True(t, errors.As(err, &fundsErr), "Expected an error as less than the minimum is available")
+	assert.Equal(t, 1100, fundsErr.Shortfall)
+}
+
+func TestCalculateSkipsLendersOutsideTheirTermLimits(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Short", Rate: 0.04, Available: 1000, MaxTerm: 24})
+	lenders = append(lenders, lender.Lender{Name: "Long", Rate: 0.06, Available: 1000, MinTerm: 36})
+
+	quote, err := Calculate(QuoteRequest{Amount: 1000, Term: 36, Lenders: lenders})
+
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+	assert.Equal(t, 1, len(quote.Allocations))
+	assert.Equal(t, "Long", quote.Allocations[0].Lender, "Expected the cheaper lender to be skipped")
+
+	quote, err = Calculate(QuoteRequest{Amount: 1000, Term: 12, Lenders: lenders})
+
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+	assert.Equal(t, "Short", quote.Allocations[0].Lender)
+
+	_, err = Calculate(QuoteRequest{Amount: 2000, Term: 36, Lenders: lenders})
+	assert.True(t, errors.Is(err, ErrInsufficientFunds), "Expected only the lenders for the term to count")
+}
+
+func TestCalculatePartialQuoteUsesStrategy(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.05, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.06, Available: 500})
+
+	// Half of the loan must come from each lender, so only £1000 can be
+	// funded even though £1500 is available.
+	quote, err := Calculate(QuoteRequest{
+		Amount:  2000,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{AllowPartial: true, Strategy: Spread{Count: 2}},
+	})
+
+	assert.Nil(t, err, "Expected a partial quote")
+	assert.Equal(t, 1000, quote.FundedAmount)
+	assert.Equal(t, 1000, quote.Shortfall)
+}
+
+func TestCalculateDoesNotModifyLenders(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+
+	original := make(lender.Lenders, len(lenders))
+	copy(original, lenders)
+
+	for _, strategy := range []Strategy{CheapestFirst{}, ProRata{}, Diversified{MaxShare: 0.9}} {
+		_, err := Calculate(QuoteRequest{Amount: 1200, Term: 36, Lenders: lenders, Options: Options{Strategy: strategy}})
+		assert.Nil(t, err, "Expected error to be nil as input information was valid")
+		assert.Equal(t, original, lenders, "Expected %s to leave the market unchanged", strategy)
+	}
+}
+
+func TestCalculateIsSafeForConcurrentUse(t *testing.T) {
+	var lenders lender.Lenders
+	for i := 0; i < 50; i++ {
+		lenders = append(lenders, lender.Lender{
+			Name:      fmt.Sprintf("Lender%d", i),
+			Rate:      0.1 - float64(i%10)/200,
+			Available: 100 + i*10,
+		})
+	}
+
+	expected, err := NewQuote(5000, 36, lenders)
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	// Quote against the shared market from many goroutines at once. Run the
+	// tests with -race to check for data races.
+	var wg sync.WaitGroup
+	quotes := make([]*Quote, 20)
+	for i := range quotes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			quotes[i], _ = NewQuote(5000, 36, lenders)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, q := range quotes {
+		assert.Equal(t, expected.Allocations, q.Allocations, "Expected every quote to be the same")
+		assert.Equal(t, expected.TotalRepayment, q.TotalRepayment)
+	}
+}
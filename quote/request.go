@@ -0,0 +1,127 @@
+package quote
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+)
+
+// QuoteRequest represents the input required to calculate a quote.
+type QuoteRequest struct {
+	// Amount is the amount the borrower wishes to borrow.
+	Amount int
+	// Currency is the code of the currency the amount is in. If not set, the
+	// currency of the lenders is used.
+	Currency string
+	// Term is the length of the loan in months.
+	Term int
+	// Lenders is the pool of lenders the loan can be funded from.
+	Lenders lender.Lenders
+	// Options holds the optional settings for the quote.
+	Options Options
+}
+
+// Options represents the optional settings that can be applied to a
+// QuoteRequest. The zero value is ready to use.
+type Options struct {
+	// StartDate is the date the loan is drawn down. If not set, the
+	// CreatedAt time is used.
+	StartDate time.Time
+	// CreatedAt is when the quote is issued. If not set, the time the quote
+	// is calculated is used.
+	CreatedAt time.Time
+	// ValidFor is how long the quote is valid for once issued. If not set,
+	// DefaultValidity is used.
+	ValidFor time.Duration
+	// Policy holds the rules the quote must satisfy. If not set, the
+	// DefaultPolicy is used.
+	Policy *Policy
+	// Rounding is the rule used to round repayments and interest to whole
+	// pennies. If not set, money.HalfUp is used.
+	Rounding money.RoundingMode
+	// Strategy decides how much is drawn from each lender. If not set,
+	// CheapestFirst is used.
+	Strategy Strategy
+	// AllowPartial allows a quote for less than the requested amount when the
+	// lenders cannot fund all of it. The quote is for the most that can be
+	// funded, rounded down to the policy step, as long as that is not below
+	// the policy minimum.
+	AllowPartial bool
+}
+
+// RequestParams represents a quote request as it is given to an API, with the
+// settings named as strings, such as the body of an HTTP request. Only the
+// amount is required.
+type RequestParams struct {
+	// Amount is the amount the borrower wishes to borrow.
+	Amount int `json:"amount"`
+	// Term is the length of the loan in months. If not set, the DefaultTerm
+	// is used.
+	Term int `json:"term"`
+	// Currency is the code of the currency the amount is in. If not set, the
+	// currency of the market is used.
+	Currency string `json:"currency"`
+	// Rounding is the name of the rounding mode, such as half-even.
+	Rounding string `json:"rounding"`
+	// Strategy is the name of the allocation strategy, such as pro-rata.
+	Strategy string `json:"strategy"`
+	// AllowPartial allows a quote for less than the amount when the market
+	// cannot fund all of it.
+	AllowPartial bool `json:"allow_partial"`
+	// StartDate is the date the loan is drawn down as YYYY-MM-DD.
+	StartDate string `json:"start_date"`
+}
+
+// Build is a function used to turn the parameters into a QuoteRequest for the
+// lenders under the policy. It is used by every API that accepts quote
+// requests, so they all apply the same defaults and checks.
+// Returns the QuoteRequest if successful, or a RequestError otherwise.
+func (p RequestParams) Build(lenders lender.Lenders, policy Policy) (QuoteRequest, error) {
+	req := QuoteRequest{
+		Amount:   p.Amount,
+		Currency: p.Currency,
+		Term:     p.Term,
+		Lenders:  lenders,
+	}
+
+	if req.Term == 0 {
+		req.Term = DefaultTerm
+	}
+
+	req.Options.Policy = &policy
+	req.Options.AllowPartial = p.AllowPartial
+
+	if p.Currency != "" {
+		if _, err := money.LookupCurrency(p.Currency); err != nil {
+			return req, NewRequestError(err.Error())
+		}
+	}
+
+	if p.Rounding != "" {
+		mode, err := money.ParseRoundingMode(p.Rounding)
+		if err != nil {
+			return req, NewRequestError(err.Error())
+		}
+		req.Options.Rounding = mode
+	}
+
+	if p.Strategy != "" {
+		strategy, err := ParseStrategy(p.Strategy)
+		if err != nil {
+			return req, NewRequestError(err.Error())
+		}
+		req.Options.Strategy = strategy
+	}
+
+	if p.StartDate != "" {
+		start, err := time.Parse("2006-01-02", p.StartDate)
+		if err != nil {
+			return req, NewRequestError(fmt.Sprintf("The start date %s is not a valid date", p.StartDate))
+		}
+		req.Options.StartDate = start
+	}
+
+	return req, nil
+}
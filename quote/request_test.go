@@ -0,0 +1,56 @@
+package quote
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestParamsBuild(t *testing.T) {
+	assert := assert.New(t)
+
+	lenders := lender.Lenders{{Name: "Jane", Rate: 0.05, Available: 1000}}
+	policy := DefaultPolicy()
+
+	req, err := RequestParams{Amount: 1000}.Build(lenders, policy)
+	assert.Nil(err)
+	assert.Equal(1000, req.Amount)
+	assert.Equal(DefaultTerm, req.Term, "Expected the default term")
+	assert.Equal(lenders, req.Lenders)
+	assert.Equal(&policy, req.Options.Policy)
+
+	req, err = RequestParams{
+		Amount:       1200,
+		Term:         24,
+		Currency:     "gbp",
+		Rounding:     "half-even",
+		Strategy:     "pro-rata",
+		AllowPartial: true,
+		StartDate:    "2015-01-15",
+	}.Build(lenders, policy)
+	assert.Nil(err)
+	assert.Equal(24, req.Term)
+	assert.Equal("gbp", req.Currency)
+	assert.Equal(money.HalfEven, req.Options.Rounding)
+	assert.Equal(ProRata{}, req.Options.Strategy)
+	assert.True(req.Options.AllowPartial)
+	assert.Equal(time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC), req.Options.StartDate)
+}
+
+func TestRequestParamsBuildFailsOnBadSettings(t *testing.T) {
+	for _, params := range []RequestParams{
+		{Amount: 1000, Currency: "XYZ"},
+		{Amount: 1000, Rounding: "down"},
+		{Amount: 1000, Strategy: "random"},
+		{Amount: 1000, StartDate: "15/01/2015"},
+	} {
+		_, err := params.Build(nil, DefaultPolicy())
+
+		var reqErr *RequestError
+		assert.True(t, errors.As(err, &reqErr), "Expected a RequestError for %+v", params)
+	}
+}
@@ -0,0 +1,257 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eazynow/goquote/money"
+)
+
+// scheduleDateFormat is the layout used when displaying payment dates.
+const scheduleDateFormat = "2006-01-02"
+
+// Payment represents a single monthly repayment within a repayment schedule.
+type Payment struct {
+	// Number is the position of the payment in the schedule, starting at 1.
+	Number int `json:"number" yaml:"number"`
+	// Date is the date the payment is due.
+	Date time.Time `json:"date" yaml:"date"`
+	// Amount is the total amount paid. It is the same every month apart from
+	// the final payment, which is adjusted to clear the remaining balance.
+	Amount money.Amount `json:"amount" yaml:"amount"`
+	// Interest is the part of the payment that covers interest.
+	Interest money.Amount `json:"interest" yaml:"interest"`
+	// Principal is the part of the payment that reduces the loan balance.
+	Principal money.Amount `json:"principal" yaml:"principal"`
+	// Fee is the part of the payment that covers the servicing fee.
+	Fee money.Amount `json:"fee" yaml:"fee"`
+	// Balance is the loan balance remaining once the payment has been made.
+	Balance money.Amount `json:"balance" yaml:"balance"`
+}
+
+// Schedule represents the month by month amortization of a quote.
+type Schedule []Payment
+
+// Schedule is a function used to get the full amortization schedule for the
+// quote. The start is the date the loan is drawn down, with the first payment
+// falling due one month later. Payments fall due on the same day of the month
+// as the start, or on the last day of the month for shorter months.
+// Returns a Schedule containing one Payment per month of the loan period.
+func (q *Quote) Schedule(start time.Time) Schedule {
+	schedule := make(Schedule, len(q.payments))
+	copy(schedule, q.payments)
+
+	for i := range schedule {
+		schedule[i].Date = dueDate(start, i+1)
+	}
+
+	return schedule
+}
+
+// dueDate is a private function that works out the date months after start,
+// keeping the day of the month unless the month is too short for it. Unlike
+// time.AddDate, a date such as 31 January never rolls over into the month
+// after the one it should fall in.
+// Returns the due date, at the same time of day as the start.
+func dueDate(start time.Time, months int) time.Time {
+	year, month, day := start.Date()
+
+	// Day zero of the following month is the last day of the month.
+	last := time.Date(year, month+time.Month(months)+1, 0, 0, 0, 0, 0, start.Location()).Day()
+	if day > last {
+		day = last
+	}
+
+	hour, min, sec := start.Clock()
+	return time.Date(year, month+time.Month(months), day, hour, min, sec, start.Nanosecond(), start.Location())
+}
+
+// loanPart is a part of the loan that is amortized at its own rate, such as
+// the amount funded by a single lender.
+type loanPart struct {
+	principal money.Amount
+	rate      float64
+}
+
+// amortize is a private function that works out the repayments for the quote
+// to the penny, once the lender allocations are known.
+// The borrower's monthly repayment is the exact repayment for the whole loan
+// rounded to the nearest penny. It is shared between the parts of the loan so
+// they add up to it exactly, and each part is then amortized separately at its
+// own rate and combined into the schedule. Each lender's allocation is a part,
+// as is any fee added to the loan, which is repaid at the blended rate.
+// Interest is rounded every month using the quote rounding mode, and the final
+// payment of each part is adjusted so the schedule reconciles with the total
+// repayment. Any servicing fee is added to every payment.
+// Returns an error if the monthly repayment is not a finite amount, or nil
+// otherwise.
+func (q *Quote) amortize() error {
+	mode := q.rounding
+
+	parts := make([]loanPart, 0, len(q.Allocations)+1)
+	for _, a := range q.Allocations {
+		parts = append(parts, loanPart{principal: money.FromMajor(a.Amount), rate: a.Rate})
+	}
+	if q.financed > 0 {
+		parts = append(parts, loanPart{principal: q.financed, rate: q.Rate})
+	}
+
+	// convert the load period into a float ready for rate calculation.
+	// You cannot mix integers and floats in computation.
+	fPeriod := float64(q.Term)
+
+	// Work out the exact monthly repayment owed for each part in pennies.
+	exact := make([]float64, len(parts))
+	total := 0.0
+	for i, p := range parts {
+		monthly := calculateMonthlyRate(p.rate, fPeriod, p.principal.Float())
+		exact[i] = monthly * 100
+		total += monthly
+	}
+
+	repayment, err := money.FromFloat(total, mode)
+	if err != nil {
+		return err
+	}
+	shares := sharePennies(exact, int64(repayment))
+
+	q.payments = make(Schedule, q.Term)
+	q.MonthlyRepayment = 0
+	q.TotalRepayment = 0
+
+	for i, p := range parts {
+		share := money.Amount(shares[i])
+		interest := q.amortizePart(p, share)
+
+		if i < len(q.Allocations) {
+			a := &q.Allocations[i]
+			a.MonthlyRepayment = share
+			a.TotalInterest = interest
+		}
+
+		q.MonthlyRepayment += share
+	}
+
+	servicing := q.rules().Fees.Servicing
+	for month := range q.payments {
+		payment := &q.payments[month]
+		payment.Number = month + 1
+		payment.Fee = servicing
+		payment.Amount += servicing
+		q.TotalRepayment += servicing
+	}
+	q.MonthlyRepayment += servicing
+	return nil
+}
+
+// amortizePart is a private function that amortizes a single part of the
+// loan with the given monthly repayment, adding it into the quote schedule and
+// total repayment.
+// Returns the total interest paid on the part.
+func (q *Quote) amortizePart(p loanPart, repayment money.Amount) money.Amount {
+	var totalInterest money.Amount
+
+	balance := p.principal
+	for month := range q.payments {
+		interest := balance.Multiply(p.rate/compoundFrequency, q.rounding)
+		principal := repayment - interest
+
+		// The final payment clears whatever is left.
+		if month == len(q.payments)-1 || principal > balance {
+			principal = balance
+		}
+		balance -= principal
+
+		payment := &q.payments[month]
+		payment.Amount += interest + principal
+		payment.Interest += interest
+		payment.Principal += principal
+		payment.Balance += balance
+
+		totalInterest += interest
+		q.TotalRepayment += interest + principal
+	}
+
+	return totalInterest
+}
+
+// sharePennies is a private function that shares a total number of pennies
+// between several parts in proportion to their exact values. Each part gets
+// its exact value rounded down, and any pennies left over go to the parts
+// that lost the most by rounding down, so the shares always add up to the
+// total.
+// Returns the number of pennies for each part.
+func sharePennies(exact []float64, total int64) []int64 {
+	shares := make([]int64, len(exact))
+	remaining := total
+
+	for i, e := range exact {
+		shares[i] = int64(math.Floor(e))
+		remaining -= shares[i]
+	}
+
+	// Order the parts by how much they lost by rounding down, largest first.
+	order := make([]int, len(exact))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return exact[order[i]]-math.Floor(exact[order[i]]) >
+			exact[order[j]]-math.Floor(exact[order[j]])
+	})
+
+	for i := 0; remaining > 0 && len(order) > 0; i++ {
+		shares[order[i%len(order)]]++
+		remaining--
+	}
+
+	return shares
+}
+
+// String is a public function to return a string representation of a
+// schedule in the money.DefaultCurrency. Used to satisfy the fmt.Stringer
+// interface.
+// Returns a string containing a table with one row per payment.
+func (s Schedule) String() string {
+	return s.Format(money.CurrencyOrDefault(money.DefaultCurrency))
+}
+
+// Format is a function used to display the schedule with amounts in the given
+// currency.
+// Returns a string containing a table with one row per payment.
+func (s Schedule) Format(c money.Currency) string {
+	var buf bytes.Buffer
+
+	// Only show the fee column when a servicing fee is charged.
+	showFee := false
+	for _, p := range s {
+		showFee = showFee || p.Fee != 0
+	}
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if showFee {
+		fmt.Fprintln(w, "No.\tDate\tPayment\tInterest\tPrincipal\tFee\tBalance\t")
+	} else {
+		fmt.Fprintln(w, "No.\tDate\tPayment\tInterest\tPrincipal\tBalance\t")
+	}
+	for _, p := range s {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t",
+			p.Number,
+			p.Date.Format(scheduleDateFormat),
+			c.Format(p.Amount),
+			c.Format(p.Interest),
+			c.Format(p.Principal))
+		if showFee {
+			fmt.Fprintf(w, "%s\t", c.Format(p.Fee))
+		}
+		fmt.Fprintf(w, "%s\t\n", c.Format(p.Balance))
+	}
+	w.Flush()
+
+	// Drop the trailing line break so it can be printed like a quote.
+	return string(bytes.TrimRight(buf.Bytes(), "\n"))
+}
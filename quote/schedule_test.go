@@ -0,0 +1,130 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleHasPaymentForEveryMonth(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.069, Available: 200})
+
+	quote, err := NewQuote(1200, 36, lenders)
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+
+	start := time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC)
+	schedule := quote.Schedule(start)
+
+	assert.Equal(36, len(schedule), "Expected a payment for each month")
+	assert.Equal(1, schedule[0].Number, "First payment should be number 1")
+	assert.Equal(36, schedule[35].Number, "Last payment should be number 36")
+	assert.Equal(time.Date(2015, time.February, 15, 0, 0, 0, 0, time.UTC), schedule[0].Date,
+		"First payment should be due one month after the start")
+	assert.Equal(time.Date(2018, time.January, 15, 0, 0, 0, 0, time.UTC), schedule[35].Date,
+		"Last payment should be due at the end of the loan period")
+}
+
+func TestScheduleKeepsMonthEndDatesInTheirMonth(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1200})
+
+	quote, err := NewQuote(1200, 36, lenders)
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	schedule := quote.Schedule(time.Date(2015, time.January, 31, 0, 0, 0, 0, time.UTC))
+
+	var dates []string
+	for _, p := range schedule[:13] {
+		dates = append(dates, p.Date.Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{
+		"2015-02-28", "2015-03-31", "2015-04-30", "2015-05-31", "2015-06-30", "2015-07-31",
+		"2015-08-31", "2015-09-30", "2015-10-31", "2015-11-30", "2015-12-31", "2016-01-31",
+		"2016-02-29",
+	}, dates, "Expected one payment a month, on the last day of shorter months")
+}
+
+func TestScheduleAmortizesTheFullAmount(t *testing.T) {
+	assert := assert.New(t)
+
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Rate: 0.069, Available: 200})
+
+	quote, err := NewQuote(1200, 36, lenders)
+	assert.Nil(err, "Expected error to be nil as input information was valid")
+
+	schedule := quote.Schedule(time.Now())
+
+	// interest on first payment = 1000 * 0.051/12 + 200 * 0.069/12
+	// interest on first payment = 4.25 + 1.15 = 5.40
+	assert.Equal("5.40", schedule[0].Interest.String(), "Expected first interest to be £5.40")
+
+	var principal, total money.Amount
+	for i, p := range schedule {
+		assert.Equal(p.Amount, p.Interest+p.Principal, "Every payment should be interest plus principal")
+		if i < len(schedule)-1 {
+			assert.Equal(quote.MonthlyRepayment, p.Amount, "Every payment but the last should match the monthly repayment")
+		}
+		principal += p.Principal
+		total += p.Amount
+	}
+
+	assert.Equal(money.FromMajor(1200), principal, "Expected the principal to be repaid in full")
+	assert.Equal(quote.TotalRepayment, total, "Expected the schedule to add up to the total repayment")
+	assert.Equal(money.Amount(0), schedule[35].Balance, "Expected nothing to be outstanding after the last payment")
+}
+
+func TestScheduleStringReturnsCorrectFormat(t *testing.T) {
+	schedule := Schedule{
+		{Number: 1, Date: time.Date(2015, time.February, 15, 0, 0, 0, 0, time.UTC), Amount: 3618, Interest: 540, Principal: 3078, Balance: 116922},
+		{Number: 2, Date: time.Date(2015, time.March, 15, 0, 0, 0, 0, time.UTC), Amount: 3618, Interest: 526, Principal: 3092, Balance: 113830},
+	}
+
+	lines := strings.Split(schedule.String(), "\n")
+
+	assert.Equal(t, 3, len(lines), "Expected a header and a line per payment")
+	assert.Equal(t, "  No.        Date  Payment  Interest  Principal   Balance", lines[0])
+	assert.Equal(t, "    1  2015-02-15   £36.18     £5.40     £30.78  £1169.22", lines[1])
+	assert.Equal(t, "    2  2015-03-15   £36.18     £5.26     £30.92  £1138.30", lines[2])
+}
+
+func TestScheduleUsesRoundingMode(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.06, Available: 1000})
+
+	// Whichever rounding mode is used, the schedule must reconcile to the
+	// penny.
+	halfUp, err := Calculate(QuoteRequest{Amount: 1000, Term: 36, Lenders: lenders})
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	halfEven, err := Calculate(QuoteRequest{
+		Amount:  1000,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{Rounding: money.HalfEven},
+	})
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	for _, q := range []*Quote{halfUp, halfEven} {
+		var total money.Amount
+		for _, p := range q.Schedule(time.Now()) {
+			total += p.Amount
+		}
+		assert.Equal(t, q.TotalRepayment, total, "Expected the schedule to reconcile for every mode")
+	}
+}
+
+func TestSharePenniesAddsUpToTotal(t *testing.T) {
+	shares := sharePennies([]float64{3001.5815509, 616.62791696}, 3618)
+
+	assert.Equal(t, []int64{3001, 617}, shares, "The spare penny should go to the largest remainder")
+}
@@ -0,0 +1,328 @@
+package quote
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eazynow/goquote/lender"
+)
+
+// Strategy represents a way of deciding how much of a loan is drawn from each
+// lender in the market. Strategies must respect the MaxLenders and
+// LenderLimit rules of the policy, and must not modify the lenders, which may
+// be shared with other quotes being calculated at the same time.
+type Strategy interface {
+	// Allocate shares the amount between the lenders.
+	// Returns the allocations, which fund less than the amount if the lenders
+	// do not have enough available.
+	Allocate(amount int, lenders lender.Lenders, policy Policy) Allocations
+	// String returns the name of the strategy as accepted by ParseStrategy.
+	String() string
+}
+
+// CheapestFirst is the default Strategy. It draws as much as possible from the
+// cheapest lender before moving on to the next, which gives the lowest rate.
+type CheapestFirst struct{}
+
+// Allocate is used to satisfy the Strategy interface.
+func (c CheapestFirst) Allocate(amount int, lenders lender.Lenders, policy Policy) Allocations {
+	return cheapestFirst(amount, lenders, policy, c.lenderLimit(amount, policy))
+}
+
+// lenderLimit is used to satisfy the limiter interface.
+func (CheapestFirst) lenderLimit(amount int, policy Policy) int {
+	return policy.LenderLimit(amount)
+}
+
+// String is used to satisfy the Strategy interface.
+func (CheapestFirst) String() string {
+	return "cheapest-first"
+}
+
+// ProRata is a Strategy that draws from every lender in proportion to how
+// much they have available, so that no lender is favoured. If the policy
+// limits the number of lenders, the cheapest are used.
+type ProRata struct{}
+
+// Allocate is used to satisfy the Strategy interface.
+func (ProRata) Allocate(amount int, lenders lender.Lenders, policy Policy) Allocations {
+	lenders = lenders.Sorted()
+
+	limit := policy.LenderLimit(amount)
+
+	// Work out how much could be drawn from each lender.
+	var (
+		used      lender.Lenders
+		capacity  []int
+		available int
+	)
+	for _, l := range lenders {
+		if policy.MaxLenders > 0 && len(used) == policy.MaxLenders {
+			break
+		}
+
+		// Share in proportion to what each lender has available, unless the
+		// policy limits how much a single lender can fund.
+		c := l.Available
+		if limit < amount && c > limit {
+			c = limit
+		}
+		if c <= 0 {
+			continue
+		}
+
+		used = append(used, l)
+		capacity = append(capacity, c)
+		available += c
+	}
+
+	// Share the amount in proportion to what each lender can lend. If there
+	// is not enough, everything available is drawn.
+	shares := capacity
+	if available > amount {
+		exact := make([]float64, len(capacity))
+		for i, c := range capacity {
+			exact[i] = float64(amount) * float64(c) / float64(available)
+		}
+
+		shares = make([]int, len(capacity))
+		for i, s := range sharePennies(exact, int64(amount)) {
+			shares[i] = int(s)
+		}
+	}
+
+	var allocations Allocations
+	for i, l := range used {
+		if shares[i] > 0 {
+			allocations = append(allocations, Allocation{Lender: l.Name, Amount: shares[i], Rate: l.Rate})
+		}
+	}
+
+	return allocations
+}
+
+// String is used to satisfy the Strategy interface.
+func (ProRata) String() string {
+	return "pro-rata"
+}
+
+// Diversified is a Strategy that draws from the cheapest lenders first, but
+// never more than MaxShare of the loan from a single lender, to spread the
+// risk of a lender defaulting. The policy MaxLenderShare still applies if it
+// is lower.
+type Diversified struct {
+	// MaxShare is the largest share of the loan, as a fraction between 0 and
+	// 1, that can be drawn from a single lender.
+	MaxShare float64
+}
+
+// Allocate is used to satisfy the Strategy interface.
+func (d Diversified) Allocate(amount int, lenders lender.Lenders, policy Policy) Allocations {
+	return cheapestFirst(amount, lenders, policy, d.lenderLimit(amount, policy))
+}
+
+// lenderLimit is used to satisfy the limiter interface.
+func (d Diversified) lenderLimit(amount int, policy Policy) int {
+	limit := policy.LenderLimit(amount)
+	if share := (Policy{MaxLenderShare: d.MaxShare}).LenderLimit(amount); share < limit {
+		limit = share
+	}
+	return limit
+}
+
+// String is used to satisfy the Strategy interface.
+func (d Diversified) String() string {
+	return fmt.Sprintf("diversified:%g", d.MaxShare)
+}
+
+// Spread is a Strategy that draws from the cheapest lenders first, but
+// spreads the loan across at least Count lenders by drawing no more than an
+// equal share from each.
+type Spread struct {
+	// Count is the fewest lenders that can fund the loan.
+	Count int
+}
+
+// Allocate is used to satisfy the Strategy interface.
+func (s Spread) Allocate(amount int, lenders lender.Lenders, policy Policy) Allocations {
+	return cheapestFirst(amount, lenders, policy, s.lenderLimit(amount, policy))
+}
+
+// lenderLimit is used to satisfy the limiter interface.
+func (s Spread) lenderLimit(amount int, policy Policy) int {
+	limit := policy.LenderLimit(amount)
+	if s.Count > 1 {
+		if share := (amount + s.Count - 1) / s.Count; share < limit {
+			limit = share
+		}
+	}
+	return limit
+}
+
+// String is used to satisfy the Strategy interface.
+func (s Spread) String() string {
+	return fmt.Sprintf("spread:%d", s.Count)
+}
+
+// FewestLenders is a Strategy that funds the loan from as few lenders as
+// possible, by drawing from the lenders with the most available first. Of the
+// lenders that can fund the same amount, the cheapest is used first. The rate
+// is usually higher than CheapestFirst, but there are fewer lenders to manage.
+type FewestLenders struct{}
+
+// Allocate is used to satisfy the Strategy interface.
+func (FewestLenders) Allocate(amount int, lenders lender.Lenders, policy Policy) Allocations {
+	limit := policy.LenderLimit(amount)
+	capacity := func(l lender.Lender) int {
+		if l.Available > limit {
+			return limit
+		}
+		return l.Available
+	}
+
+	// Sort into order of rate first, so that the stable sort by capacity
+	// keeps the cheapest first for lenders that can fund the same amount.
+	lenders = lenders.Sorted()
+	sort.SliceStable(lenders, func(i, j int) bool {
+		return capacity(lenders[i]) > capacity(lenders[j])
+	})
+
+	d := newDrawer(amount, policy, limit)
+	for _, l := range lenders {
+		if d.draw(l) {
+			break
+		}
+	}
+
+	return d.allocations
+}
+
+// String is used to satisfy the Strategy interface.
+func (FewestLenders) String() string {
+	return "fewest-lenders"
+}
+
+// limiter is implemented by the strategies that draw from the cheapest
+// lenders first, taking no more than a limit from each. They only need to see
+// the lenders in order of preference one at a time, so they can be used with
+// CalculateStream.
+type limiter interface {
+	// lenderLimit returns the most that can be drawn from a single lender for
+	// a loan of the given amount.
+	lenderLimit(amount int, policy Policy) int
+}
+
+// cheapestFirst is a private function that draws from the lenders in order of
+// rate, taking no more than limit from any one of them.
+// Returns the allocations.
+func cheapestFirst(amount int, lenders lender.Lenders, policy Policy, limit int) Allocations {
+	d := newDrawer(amount, policy, limit)
+
+	// sort lenders into order of preference ascending order (based on rate),
+	// leaving the caller's slice untouched.
+	for _, l := range lenders.Sorted() {
+		if d.draw(l) {
+			break
+		}
+	}
+
+	return d.allocations
+}
+
+// drawer shares a loan between lenders seen one at a time in order of
+// preference, taking no more than a limit from each of them.
+type drawer struct {
+	policy      Policy
+	limit       int
+	balance     int
+	allocations Allocations
+}
+
+// newDrawer is a private function for creating a drawer for a loan of the
+// given amount.
+// Returns a pointer to the new drawer.
+func newDrawer(amount int, policy Policy, limit int) *drawer {
+	return &drawer{policy: policy, limit: limit, balance: amount}
+}
+
+// done is a private function that checks whether any more can be drawn.
+// Returns true once the loan is funded or the policy's maximum number of
+// lenders has been used.
+func (d *drawer) done() bool {
+	return d.balance == 0 ||
+		(d.policy.MaxLenders > 0 && len(d.allocations) == d.policy.MaxLenders)
+}
+
+// draw is a private function that draws as much as it can from the next
+// lender.
+// Returns true once no more can be drawn from any lender.
+func (d *drawer) draw(l lender.Lender) bool {
+	if d.done() {
+		return true
+	}
+
+	// Find out how much we can borrow from this lender, without going over
+	// the limit for a single lender.
+	borrow := l.Borrow(d.balance)
+	if borrow > d.limit {
+		borrow = d.limit
+	}
+	if borrow <= 0 {
+		return false
+	}
+
+	// Record who is lending what so the loan can be funded. The repayments
+	// are worked out once all the lenders are known.
+	d.allocations = append(d.allocations, Allocation{
+		Lender: l.Name,
+		Amount: borrow,
+		Rate:   l.Rate,
+	})
+	d.balance -= borrow
+
+	return d.done()
+}
+
+// ParseStrategy is a function used to convert the name of a strategy, such
+// as one passed on the command line, into a Strategy. Strategies that take a
+// setting have it after a colon, such as "diversified:0.25" or
+// "spread:3". The other names are "cheapest-first", "pro-rata" and
+// "fewest-lenders".
+// Returns the Strategy if the name is recognised, or an error otherwise.
+func ParseStrategy(name string) (Strategy, error) {
+	kind, setting := strings.ToLower(name), ""
+	if i := strings.Index(kind, ":"); i >= 0 {
+		kind, setting = kind[:i], kind[i+1:]
+	}
+
+	switch kind {
+	case "cheapest-first":
+		if setting == "" {
+			return CheapestFirst{}, nil
+		}
+	case "pro-rata":
+		if setting == "" {
+			return ProRata{}, nil
+		}
+	case "fewest-lenders":
+		if setting == "" {
+			return FewestLenders{}, nil
+		}
+	case "diversified":
+		share, err := strconv.ParseFloat(setting, 64)
+		if err == nil && share > 0 && share <= 1 {
+			return Diversified{MaxShare: share}, nil
+		}
+		return nil, fmt.Errorf("The diversified strategy needs a share between 0 and 1, such as diversified:0.25")
+	case "spread":
+		count, err := strconv.Atoi(setting)
+		if err == nil && count > 0 {
+			return Spread{Count: count}, nil
+		}
+		return nil, fmt.Errorf("The spread strategy needs a number of lenders, such as spread:3")
+	}
+
+	return nil, fmt.Errorf("Unknown allocation strategy %q", name)
+}
@@ -0,0 +1,179 @@
+package quote
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStrategyMarket creates a market used by the strategy tests.
+func newStrategyMarket() lender.Lenders {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "C", Rate: 0.07, Available: 100})
+	lenders = append(lenders, lender.Lender{Name: "A", Rate: 0.05, Available: 600})
+	lenders = append(lenders, lender.Lender{Name: "B", Rate: 0.06, Available: 300})
+	return lenders
+}
+
+func TestCheapestFirstAllocate(t *testing.T) {
+	allocations := CheapestFirst{}.Allocate(500, newStrategyMarket(), Policy{})
+
+	assert.Equal(t, Allocations{
+		{Lender: "A", Amount: 500, Rate: 0.05},
+	}, allocations)
+}
+
+func TestProRataAllocate(t *testing.T) {
+	allocations := ProRata{}.Allocate(500, newStrategyMarket(), Policy{})
+
+	// Each lender funds half of what they have available.
+	assert.Equal(t, Allocations{
+		{Lender: "A", Amount: 300, Rate: 0.05},
+		{Lender: "B", Amount: 150, Rate: 0.06},
+		{Lender: "C", Amount: 50, Rate: 0.07},
+	}, allocations)
+}
+
+func TestProRataAllocateSharesRemainders(t *testing.T) {
+	allocations := ProRata{}.Allocate(100, newStrategyMarket(), Policy{})
+
+	total := 0
+	for _, a := range allocations {
+		total += a.Amount
+	}
+	assert.Equal(t, 100, total, "Expected the shares to add up to the amount")
+}
+
+func TestProRataAllocateRespectsPolicy(t *testing.T) {
+	allocations := ProRata{}.Allocate(500, newStrategyMarket(), Policy{MaxLenders: 2, MaxLenderShare: 0.5})
+
+	// A and B can each lend up to 250, so each funds half.
+	assert.Equal(t, Allocations{
+		{Lender: "A", Amount: 250, Rate: 0.05},
+		{Lender: "B", Amount: 250, Rate: 0.06},
+	}, allocations)
+}
+
+func TestProRataAllocateDrawsEverythingWhenShort(t *testing.T) {
+	allocations := ProRata{}.Allocate(1200, newStrategyMarket(), Policy{})
+
+	assert.Equal(t, 3, len(allocations))
+	assert.Equal(t, 600, allocations[0].Amount)
+}
+
+func TestDiversifiedAllocate(t *testing.T) {
+	allocations := Diversified{MaxShare: 0.5}.Allocate(500, newStrategyMarket(), Policy{})
+
+	assert.Equal(t, Allocations{
+		{Lender: "A", Amount: 250, Rate: 0.05},
+		{Lender: "B", Amount: 250, Rate: 0.06},
+	}, allocations)
+
+	// The policy limit applies when it is lower.
+	allocations = Diversified{MaxShare: 0.5}.Allocate(500, newStrategyMarket(), Policy{MaxLenderShare: 0.4})
+	assert.Equal(t, 200, allocations[0].Amount)
+}
+
+func TestSpreadAllocate(t *testing.T) {
+	allocations := Spread{Count: 3}.Allocate(300, newStrategyMarket(), Policy{})
+
+	assert.Equal(t, Allocations{
+		{Lender: "A", Amount: 100, Rate: 0.05},
+		{Lender: "B", Amount: 100, Rate: 0.06},
+		{Lender: "C", Amount: 100, Rate: 0.07},
+	}, allocations)
+}
+
+func TestFewestLendersAllocate(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "A", Rate: 0.05, Available: 300})
+	lenders = append(lenders, lender.Lender{Name: "B", Rate: 0.06, Available: 300})
+	lenders = append(lenders, lender.Lender{Name: "C", Rate: 0.07, Available: 800})
+	lenders = append(lenders, lender.Lender{Name: "D", Rate: 0.08, Available: 800})
+
+	allocations := FewestLenders{}.Allocate(1000, lenders, Policy{})
+
+	// Cheapest first would use A, B and C, but C and D fund it alone, with
+	// the cheaper C drawn from first.
+	assert.Equal(t, Allocations{
+		{Lender: "C", Amount: 800, Rate: 0.07},
+		{Lender: "D", Amount: 200, Rate: 0.08},
+	}, allocations)
+	assert.Equal(t, 300, lenders[0].Available, "Expected the lenders to be unchanged")
+	assert.Equal(t, "A", lenders[0].Name, "Expected the order of the lenders to be unchanged")
+}
+
+func TestFewestLendersAllocateRespectsPolicy(t *testing.T) {
+	allocations := FewestLenders{}.Allocate(500, newStrategyMarket(), Policy{MaxLenderShare: 0.5})
+
+	// A and B can each lend up to 250, so the cheaper A is used first.
+	assert.Equal(t, Allocations{
+		{Lender: "A", Amount: 250, Rate: 0.05},
+		{Lender: "B", Amount: 250, Rate: 0.06},
+	}, allocations)
+}
+
+func TestCalculateUsesStrategy(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.05, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.07, Available: 1000})
+
+	cheapest, err := Calculate(QuoteRequest{Amount: 1000, Term: 36, Lenders: lenders})
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	proRata, err := Calculate(QuoteRequest{
+		Amount:  1000,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{Strategy: ProRata{}},
+	})
+	assert.Nil(t, err, "Expected error to be nil as input information was valid")
+
+	assert.Equal(t, 1, len(cheapest.Allocations))
+	assert.Equal(t, 2, len(proRata.Allocations))
+	assert.InDelta(t, 0.06, proRata.Rate, 1e-9, "Expected an even split to blend the rates")
+	assert.True(t, proRata.MonthlyRepayment > cheapest.MonthlyRepayment)
+}
+
+func TestCalculateFailsWhenStrategyCannotFund(t *testing.T) {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Rate: 0.05, Available: 2000})
+
+	_, err := Calculate(QuoteRequest{
+		Amount:  1000,
+		Term:    36,
+		Lenders: lenders,
+		Options: Options{Strategy: Spread{Count: 2}},
+	})
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds), "Expected ErrInsufficientFunds as there is only one lender")
+}
+
+func TestParseStrategy(t *testing.T) {
+	for name, expected := range map[string]Strategy{
+		"cheapest-first":   CheapestFirst{},
+		"Pro-Rata":         ProRata{},
+		"diversified:0.25": Diversified{MaxShare: 0.25},
+		"spread:3":         Spread{Count: 3},
+		"fewest-lenders":   FewestLenders{},
+	} {
+		s, err := ParseStrategy(name)
+		assert.Nil(t, err, "Expected %q to parse", name)
+		assert.Equal(t, expected, s)
+	}
+
+	for _, name := range []string{"", "greedy", "pro-rata:2", "diversified", "diversified:2", "spread:none", "min-lenders:3", "fewest-lenders:2"} {
+		_, err := ParseStrategy(name)
+		assert.NotNil(t, err, "Expected %q to fail", name)
+	}
+}
+
+func TestStrategyString(t *testing.T) {
+	for _, s := range []Strategy{CheapestFirst{}, ProRata{}, Diversified{MaxShare: 0.25}, Spread{Count: 3}, FewestLenders{}} {
+		parsed, err := ParseStrategy(s.String())
+		assert.Nil(t, err, "Expected %q to parse", s)
+		assert.Equal(t, s, parsed, "Expected the name to round trip")
+	}
+}
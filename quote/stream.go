@@ -0,0 +1,132 @@
+package quote
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+)
+
+var (
+	// ErrUnsortedMarket is used when the lenders passed to CalculateStream are
+	// not in order of preference.
+	ErrUnsortedMarket = errors.New("market is not sorted in order of preference")
+	// ErrStreamStrategy is used when the strategy passed to CalculateStream
+	// needs to see every lender at once, such as ProRata.
+	ErrStreamStrategy = errors.New("allocation strategy cannot quote from a stream")
+)
+
+// LenderStream represents a market read one lender at a time, such as a
+// lender.Stream.
+type LenderStream interface {
+	// Next moves on to the next lender, returning false at the end of the
+	// market.
+	Next() bool
+	// Lender returns the lender found by the last call to Next.
+	Lender() lender.Lender
+	// Err returns the error that ended the market early, if any.
+	Err() error
+}
+
+// CalculateStream is a function used to generate a quote in the same way as
+// Calculate, but from lenders read one at a time instead of the Lenders of
+// the request, so that a market too large to hold in memory can be quoted
+// from. The lenders must already be in order of preference, as given by
+// lender.Lenders.Sorted, and the quote is the same as Calculate would give
+// for the same lenders. Only the lenders the loan is drawn from are kept, so
+// the memory used depends on the size of the loan rather than of the market.
+// The amount and term are checked against the policy before the market is
+// read, but every lender is then read, to check the order and currency of the
+// market and to work out its hash. Strategies that need every lender at once,
+// such as ProRata, cannot be used.
+// Returns a pointer to a Quote structure with the quote details if
+// successful. If the lenders are out of order, ErrUnsortedMarket is
+// returned, and any other validation, calculation or stream error is returned
+// as it would be by Calculate, except that a request that breaks the policy
+// is rejected before any market error is found.
+func CalculateStream(req QuoteRequest, lenders LenderStream) (*Quote, error) {
+	quote := newQuote(req)
+
+	strategy := quote.allocator()
+	limits, ok := strategy.(limiter)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStreamStrategy, strategy)
+	}
+
+	// Check the request before the market is read, so that a request that
+	// breaks the policy is not kept waiting for a large market. Only the first
+	// lender is needed, for the currency the amounts are shown in.
+	more := lenders.Next()
+	quote.Currency = money.DefaultCurrency
+	if more {
+		first := lenders.Lender()
+		quote.Currency = first.CurrencyCode()
+	}
+	if err := quote.validate(); err != nil {
+		return nil, err
+	}
+
+	// Draw from the lenders that will lend over the term as they are read,
+	// keeping only those that are drawn from.
+	var (
+		policy      = quote.rules()
+		d           = newDrawer(req.Amount, policy, limits.lenderLimit(req.Amount, policy))
+		fingerprint = lender.NewFingerprint()
+		drawn       lender.Lenders
+		currency    string
+		previous    lender.Lender
+	)
+	for count := 0; more; count, more = count+1, lenders.Next() {
+		l := lenders.Lender()
+		fingerprint.Add(l)
+
+		// All of the lenders must lend in the same currency.
+		code := l.CurrencyCode()
+		if currency != "" && code != currency {
+			return nil, lender.ErrMixedCurrencies
+		}
+		currency = code
+
+		if count > 0 && l.Before(&previous) {
+			return nil, fmt.Errorf("%w: lender %d (%s) should come before the lender above it", ErrUnsortedMarket, count+1, l.Name)
+		}
+		previous = l
+
+		if !d.done() && l.Lends(quote.Term) {
+			before := len(d.allocations)
+			d.draw(l)
+			if len(d.allocations) > before {
+				drawn = append(drawn, l)
+			}
+		}
+	}
+	if err := lenders.Err(); err != nil {
+		return nil, err
+	}
+
+	quote.MarketHash = fingerprint.Sum()
+	quote.lenders = drawn
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	// The currency that was requested must be the currency of the market.
+	if err := quote.setCurrency(req.Currency, currency); err != nil {
+		return nil, err
+	}
+
+	// A partial quote only ever needs the lenders that were drawn from, as
+	// a smaller loan draws from the same lenders in the same order.
+	quote.FundedAmount = quote.RequestedAmount
+	quote.Allocations = d.allocations
+	if err := quote.fund(strategy, drawn); err != nil {
+		return nil, err
+	}
+
+	if err := quote.price(); err != nil {
+		return nil, err
+	}
+
+	return quote.issue()
+}
@@ -0,0 +1,290 @@
+package quote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceStream streams the lenders of a slice.
+type sliceStream struct {
+	lenders lender.Lenders
+	next    int
+	err     error
+}
+
+func (s *sliceStream) Next() bool {
+	if s.next >= len(s.lenders) {
+		return false
+	}
+	s.next++
+	return true
+}
+
+func (s *sliceStream) Lender() lender.Lender {
+	return s.lenders[s.next-1]
+}
+
+func (s *sliceStream) Err() error {
+	return s.err
+}
+
+// streamTestMarket returns a market of lenders with varied rates, amounts and
+// term limits, in order of preference.
+func streamTestMarket() lender.Lenders {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.075, Available: 640})
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.069, Available: 480, MaxTerm: 24})
+	lenders = append(lenders, lender.Lender{Name: "Fred", Rate: 0.071, Available: 520})
+	lenders = append(lenders, lender.Lender{Name: "Mary", Rate: 0.104, Available: 170})
+	lenders = append(lenders, lender.Lender{Name: "John", Rate: 0.081, Available: 320})
+	lenders = append(lenders, lender.Lender{Name: "Dave", Rate: 0.074, Available: 140})
+	lenders = append(lenders, lender.Lender{Name: "Angela", Rate: 0.071, Available: 60})
+	return lenders.Sorted()
+}
+
+// assertSameQuote checks that two quotes offer the same loan.
+func assertSameQuote(t *testing.T, expected, actual *Quote) {
+	assert.Equal(t, expected.MarketHash, actual.MarketHash)
+	assert.Equal(t, expected.Currency, actual.Currency)
+	assert.Equal(t, expected.FundedAmount, actual.FundedAmount)
+	assert.Equal(t, expected.Shortfall, actual.Shortfall)
+	assert.Equal(t, expected.Allocations, actual.Allocations)
+	assert.Equal(t, expected.Rate, actual.Rate)
+	assert.Equal(t, expected.APR, actual.APR)
+	assert.Equal(t, expected.MonthlyRepayment, actual.MonthlyRepayment)
+	assert.Equal(t, expected.TotalRepayment, actual.TotalRepayment)
+	assert.Equal(t, expected.ExpiresAt, actual.ExpiresAt)
+}
+
+func TestCalculateStreamMatchesCalculate(t *testing.T) {
+	created := time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name    string
+		amount  int
+		term    int
+		options Options
+	}{
+		{"cheapest first", 1000, 36, Options{}},
+		{"term limits", 1000, 12, Options{}},
+		{"diversified", 1000, 36, Options{Strategy: Diversified{MaxShare: 0.3}}},
+		{"spread", 1200, 36, Options{Strategy: Spread{Count: 4}}},
+		{"max lenders", 1000, 36, Options{Policy: &Policy{MinAmount: 100, MaxAmount: 5000, Step: 100, MaxLenders: 2}, AllowPartial: true}},
+		{"partial", 3000, 36, Options{AllowPartial: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.options.CreatedAt = created
+			req := QuoteRequest{Amount: tc.amount, Term: tc.term, Lenders: streamTestMarket(), Options: tc.options}
+
+			expected, err := Calculate(req)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			actual, err := CalculateStream(QuoteRequest{Amount: tc.amount, Term: tc.term, Options: tc.options},
+				&sliceStream{lenders: streamTestMarket()})
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			assertSameQuote(t, expected, actual)
+			assert.NotEqual(t, "", actual.ID, "Expected the quote to have an ID")
+		})
+	}
+}
+
+func TestCalculateStreamFromImportedMarket(t *testing.T) {
+	market := "Lender,Rate,Available\nJane,0.069,480\nFred,0.071,520\nAngela,0.071,60\nBob,0.075,640\n"
+	s, err := lender.NewStream(strings.NewReader(market), lender.ImportOptions{})
+	assert.Nil(t, err)
+
+	q, err := CalculateStream(QuoteRequest{Amount: 1000, Term: 36}, s)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, q.FundedAmount)
+	assert.Equal(t, 2, len(q.Allocations))
+	assert.Equal(t, "GBP", q.Currency)
+}
+
+func TestCalculateStreamFailsWhenUnsorted(t *testing.T) {
+	lenders := streamTestMarket()
+	lenders[1], lenders[2] = lenders[2], lenders[1]
+
+	_, err := CalculateStream(QuoteRequest{Amount: 1000, Term: 36}, &sliceStream{lenders: lenders})
+
+	assert.True(t, errors.Is(err, ErrUnsortedMarket), "Expected an error as the lenders are out of order")
+}
+
+func TestCalculateStreamFailsWithStrategiesThatNeedEveryLender(t *testing.T) {
+	for _, strategy := range []Strategy{ProRata{}, FewestLenders{}} {
+		_, err := CalculateStream(QuoteRequest{Amount: 1000, Term: 36, Options: Options{Strategy: strategy}},
+			&sliceStream{lenders: streamTestMarket()})
+
+		assert.True(t, errors.Is(err, ErrStreamStrategy), "Expected %s to need every lender", strategy)
+	}
+}
+
+func TestCalculateStreamFailsWithMixedCurrencies(t *testing.T) {
+	lenders := streamTestMarket()
+	lenders[len(lenders)-1].Currency = "EUR"
+
+	_, err := CalculateStream(QuoteRequest{Amount: 1000, Term: 36}, &sliceStream{lenders: lenders})
+
+	assert.True(t, errors.Is(err, lender.ErrMixedCurrencies), "Expected the whole market to be checked")
+}
+
+func TestCalculateStreamFailsOnStreamError(t *testing.T) {
+	streamErr := errors.New("connection lost")
+
+	_, err := CalculateStream(QuoteRequest{Amount: 1000, Term: 36},
+		&sliceStream{lenders: streamTestMarket(), err: streamErr})
+
+	assert.Equal(t, streamErr, err)
+}
+
+func TestCalculateStreamRejectsRequestBeforeReadingMarket(t *testing.T) {
+	lenders := streamTestMarket()
+	for i := range lenders {
+		lenders[i].Currency = "EUR"
+	}
+
+	for _, req := range []QuoteRequest{
+		{Amount: 900, Term: 36},
+		{Amount: 1000, Term: 0},
+	} {
+		s := &sliceStream{lenders: lenders}
+
+		_, err := CalculateStream(req, s)
+
+		assert.True(t, IsRejection(err), "Expected %+v to be rejected", req)
+		assert.Equal(t, 1, s.next, "Expected only the first lender to be read")
+	}
+
+	_, err := CalculateStream(QuoteRequest{Amount: 900, Term: 36}, &sliceStream{lenders: lenders})
+	assert.Equal(t, "Loan amount of €900 is too low. Minimum loan amount is €1000", err.Error())
+}
+
+func TestCalculateStreamFailsWhenAmountNotAvailable(t *testing.T) {
+	_, err := CalculateStream(QuoteRequest{Amount: 5000, Term: 36}, &sliceStream{lenders: streamTestMarket()})
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds))
+}
+
+// marketReader generates a csv market of n lenders in order of preference, a
+// row at a time, so that a benchmark market never has to be held in memory.
+type marketReader struct {
+	n, row int
+	buf    []byte
+}
+
+func (m *marketReader) Read(p []byte) (int, error) {
+	for len(m.buf) == 0 {
+		switch {
+		case m.row > m.n:
+			return 0, io.EOF
+		case m.row == 0:
+			m.buf = []byte("Lender,Rate,Available\n")
+		default:
+			rate := 0.04 + 0.06*float64(m.row)/float64(m.n)
+			m.buf = fmt.Appendf(m.buf[:0], "Lender%d,%.9f,%d\n", m.row, rate, 500)
+		}
+		m.row++
+	}
+
+	n := copy(p, m.buf)
+	m.buf = m.buf[n:]
+	return n, nil
+}
+
+// reportPeakHeap runs f while sampling the heap, and reports the most it grew
+// by as the peak-heap-MB metric.
+func reportPeakHeap(b *testing.B, f func()) {
+	runtime.GC()
+	var base runtime.MemStats
+	runtime.ReadMemStats(&base)
+
+	stop := make(chan struct{})
+	peak := make(chan uint64)
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+
+		var max uint64
+		for {
+			var m runtime.MemStats
+			runtime.ReadMemStats(&m)
+			if m.HeapAlloc > max {
+				max = m.HeapAlloc
+			}
+
+			select {
+			case <-stop:
+				peak <- max
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	f()
+	close(stop)
+
+	grown := 0.0
+	if max := <-peak; max > base.HeapAlloc {
+		grown = float64(max-base.HeapAlloc) / (1 << 20)
+	}
+	b.ReportMetric(grown, "peak-heap-MB")
+}
+
+// benchmarkSizes are the number of lenders in the benchmark markets.
+var benchmarkSizes = []int{10000, 100000, 1000000}
+
+// BenchmarkCalculate imports the whole market before quoting, so the memory
+// used grows with the market.
+func BenchmarkCalculate(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("lenders=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			reportPeakHeap(b, func() {
+				for i := 0; i < b.N; i++ {
+					lenders, err := lender.ReadCSV(&marketReader{n: n}, lender.ImportOptions{})
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := Calculate(QuoteRequest{Amount: MaxAmount, Term: DefaultTerm, Lenders: lenders}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkCalculateStream quotes while the market is read, so the memory
+// used stays the same however large the market is.
+func BenchmarkCalculateStream(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("lenders=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			reportPeakHeap(b, func() {
+				for i := 0; i < b.N; i++ {
+					s, err := lender.NewStream(&marketReader{n: n}, lender.ImportOptions{Format: lender.FormatCSV})
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := CalculateStream(QuoteRequest{Amount: MaxAmount, Term: DefaultTerm}, s); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
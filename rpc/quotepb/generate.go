@@ -0,0 +1,6 @@
+// Package quotepb holds the protobuf messages and gRPC service definition for
+// the quote service. The Go code is generated from quote.proto with protoc,
+// protoc-gen-go and protoc-gen-go-grpc; run go generate after changing it.
+package quotepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative quote.proto
@@ -0,0 +1,922 @@
+// The gRPC interface to the goquote quoting engine.
+//
+// Amounts that are always whole, such as the amount requested or lent, are in
+// major units such as pounds. Amounts that can include pennies, such as
+// repayments, are in minor units and have a _minor suffix.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: quote.proto
+
+package quotepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CreateQuoteRequest is the loan to quote for. Only the amount is required.
+type CreateQuoteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The amount the borrower wishes to borrow.
+	Amount int64 `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	// The length of the loan in months. Defaults to 36.
+	Term int32 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	// The code of the currency the amount is in. Defaults to the currency of
+	// the market.
+	Currency string `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	// The rounding mode, half-up or half-even. Defaults to half-up.
+	Rounding string `protobuf:"bytes,4,opt,name=rounding,proto3" json:"rounding,omitempty"`
+	// The allocation strategy, such as pro-rata. Defaults to cheapest-first.
+	Strategy string `protobuf:"bytes,5,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	// Quote for as much as the market can fund if it cannot fund the amount.
+	AllowPartial bool `protobuf:"varint,6,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"`
+	// The date the loan is drawn down. Defaults to now.
+	StartDate *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	// Include the repayment schedule in the quote.
+	IncludeSchedule bool `protobuf:"varint,8,opt,name=include_schedule,json=includeSchedule,proto3" json:"include_schedule,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreateQuoteRequest) Reset() {
+	*x = CreateQuoteRequest{}
+	mi := &file_quote_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateQuoteRequest) ProtoMessage() {}
+
+func (x *CreateQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateQuoteRequest.ProtoReflect.Descriptor instead.
+func (*CreateQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateQuoteRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *CreateQuoteRequest) GetTerm() int32 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *CreateQuoteRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetRounding() string {
+	if x != nil {
+		return x.Rounding
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+func (x *CreateQuoteRequest) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *CreateQuoteRequest) GetIncludeSchedule() bool {
+	if x != nil {
+		return x.IncludeSchedule
+	}
+	return false
+}
+
+// Quote is a calculated loan quote.
+type Quote struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	PolicyVersion   string                 `protobuf:"bytes,4,opt,name=policy_version,json=policyVersion,proto3" json:"policy_version,omitempty"`
+	MarketHash      string                 `protobuf:"bytes,5,opt,name=market_hash,json=marketHash,proto3" json:"market_hash,omitempty"`
+	RequestedAmount int64                  `protobuf:"varint,6,opt,name=requested_amount,json=requestedAmount,proto3" json:"requested_amount,omitempty"`
+	FundedAmount    int64                  `protobuf:"varint,7,opt,name=funded_amount,json=fundedAmount,proto3" json:"funded_amount,omitempty"`
+	Shortfall       int64                  `protobuf:"varint,8,opt,name=shortfall,proto3" json:"shortfall,omitempty"`
+	Currency        string                 `protobuf:"bytes,9,opt,name=currency,proto3" json:"currency,omitempty"`
+	Term            int32                  `protobuf:"varint,10,opt,name=term,proto3" json:"term,omitempty"`
+	StartDate       *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	// The nominal annual rate as a fraction, such as 0.07.
+	Rate float64 `protobuf:"fixed64,12,opt,name=rate,proto3" json:"rate,omitempty"`
+	// The annual percentage rate of charge as a fraction.
+	Apr                    float64       `protobuf:"fixed64,13,opt,name=apr,proto3" json:"apr,omitempty"`
+	MonthlyRepaymentMinor  int64         `protobuf:"varint,14,opt,name=monthly_repayment_minor,json=monthlyRepaymentMinor,proto3" json:"monthly_repayment_minor,omitempty"`
+	TotalRepaymentMinor    int64         `protobuf:"varint,15,opt,name=total_repayment_minor,json=totalRepaymentMinor,proto3" json:"total_repayment_minor,omitempty"`
+	AmountReceivedMinor    int64         `protobuf:"varint,16,opt,name=amount_received_minor,json=amountReceivedMinor,proto3" json:"amount_received_minor,omitempty"`
+	TotalCostOfCreditMinor int64         `protobuf:"varint,17,opt,name=total_cost_of_credit_minor,json=totalCostOfCreditMinor,proto3" json:"total_cost_of_credit_minor,omitempty"`
+	Fees                   []*Fee        `protobuf:"bytes,18,rep,name=fees,proto3" json:"fees,omitempty"`
+	Allocations            []*Allocation `protobuf:"bytes,19,rep,name=allocations,proto3" json:"allocations,omitempty"`
+	// Only set when include_schedule was requested.
+	Schedule      []*Payment `protobuf:"bytes,20,rep,name=schedule,proto3" json:"schedule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_quote_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Quote) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Quote) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Quote) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Quote) GetPolicyVersion() string {
+	if x != nil {
+		return x.PolicyVersion
+	}
+	return ""
+}
+
+func (x *Quote) GetMarketHash() string {
+	if x != nil {
+		return x.MarketHash
+	}
+	return ""
+}
+
+func (x *Quote) GetRequestedAmount() int64 {
+	if x != nil {
+		return x.RequestedAmount
+	}
+	return 0
+}
+
+func (x *Quote) GetFundedAmount() int64 {
+	if x != nil {
+		return x.FundedAmount
+	}
+	return 0
+}
+
+func (x *Quote) GetShortfall() int64 {
+	if x != nil {
+		return x.Shortfall
+	}
+	return 0
+}
+
+func (x *Quote) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Quote) GetTerm() int32 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *Quote) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *Quote) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Quote) GetApr() float64 {
+	if x != nil {
+		return x.Apr
+	}
+	return 0
+}
+
+func (x *Quote) GetMonthlyRepaymentMinor() int64 {
+	if x != nil {
+		return x.MonthlyRepaymentMinor
+	}
+	return 0
+}
+
+func (x *Quote) GetTotalRepaymentMinor() int64 {
+	if x != nil {
+		return x.TotalRepaymentMinor
+	}
+	return 0
+}
+
+func (x *Quote) GetAmountReceivedMinor() int64 {
+	if x != nil {
+		return x.AmountReceivedMinor
+	}
+	return 0
+}
+
+func (x *Quote) GetTotalCostOfCreditMinor() int64 {
+	if x != nil {
+		return x.TotalCostOfCreditMinor
+	}
+	return 0
+}
+
+func (x *Quote) GetFees() []*Fee {
+	if x != nil {
+		return x.Fees
+	}
+	return nil
+}
+
+func (x *Quote) GetAllocations() []*Allocation {
+	if x != nil {
+		return x.Allocations
+	}
+	return nil
+}
+
+func (x *Quote) GetSchedule() []*Payment {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+// Fee is a single fee charged on a quote.
+type Fee struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// arrangement, origination or servicing.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// upfront, financed or monthly.
+	Timing        string `protobuf:"bytes,2,opt,name=timing,proto3" json:"timing,omitempty"`
+	AmountMinor   int64  `protobuf:"varint,3,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"`
+	TotalMinor    int64  `protobuf:"varint,4,opt,name=total_minor,json=totalMinor,proto3" json:"total_minor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fee) Reset() {
+	*x = Fee{}
+	mi := &file_quote_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fee) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fee) ProtoMessage() {}
+
+func (x *Fee) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fee.ProtoReflect.Descriptor instead.
+func (*Fee) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Fee) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Fee) GetTiming() string {
+	if x != nil {
+		return x.Timing
+	}
+	return ""
+}
+
+func (x *Fee) GetAmountMinor() int64 {
+	if x != nil {
+		return x.AmountMinor
+	}
+	return 0
+}
+
+func (x *Fee) GetTotalMinor() int64 {
+	if x != nil {
+		return x.TotalMinor
+	}
+	return 0
+}
+
+// Allocation is the part of a loan funded by a single lender.
+type Allocation struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Lender                string                 `protobuf:"bytes,1,opt,name=lender,proto3" json:"lender,omitempty"`
+	Amount                int64                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Rate                  float64                `protobuf:"fixed64,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	MonthlyRepaymentMinor int64                  `protobuf:"varint,4,opt,name=monthly_repayment_minor,json=monthlyRepaymentMinor,proto3" json:"monthly_repayment_minor,omitempty"`
+	TotalInterestMinor    int64                  `protobuf:"varint,5,opt,name=total_interest_minor,json=totalInterestMinor,proto3" json:"total_interest_minor,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Allocation) Reset() {
+	*x = Allocation{}
+	mi := &file_quote_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Allocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Allocation) ProtoMessage() {}
+
+func (x *Allocation) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Allocation.ProtoReflect.Descriptor instead.
+func (*Allocation) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Allocation) GetLender() string {
+	if x != nil {
+		return x.Lender
+	}
+	return ""
+}
+
+func (x *Allocation) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Allocation) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Allocation) GetMonthlyRepaymentMinor() int64 {
+	if x != nil {
+		return x.MonthlyRepaymentMinor
+	}
+	return 0
+}
+
+func (x *Allocation) GetTotalInterestMinor() int64 {
+	if x != nil {
+		return x.TotalInterestMinor
+	}
+	return 0
+}
+
+// Payment is a single monthly repayment in the repayment schedule.
+type Payment struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Number         int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Date           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	AmountMinor    int64                  `protobuf:"varint,3,opt,name=amount_minor,json=amountMinor,proto3" json:"amount_minor,omitempty"`
+	InterestMinor  int64                  `protobuf:"varint,4,opt,name=interest_minor,json=interestMinor,proto3" json:"interest_minor,omitempty"`
+	PrincipalMinor int64                  `protobuf:"varint,5,opt,name=principal_minor,json=principalMinor,proto3" json:"principal_minor,omitempty"`
+	FeeMinor       int64                  `protobuf:"varint,6,opt,name=fee_minor,json=feeMinor,proto3" json:"fee_minor,omitempty"`
+	BalanceMinor   int64                  `protobuf:"varint,7,opt,name=balance_minor,json=balanceMinor,proto3" json:"balance_minor,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_quote_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Payment) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Payment) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Payment) GetAmountMinor() int64 {
+	if x != nil {
+		return x.AmountMinor
+	}
+	return 0
+}
+
+func (x *Payment) GetInterestMinor() int64 {
+	if x != nil {
+		return x.InterestMinor
+	}
+	return 0
+}
+
+func (x *Payment) GetPrincipalMinor() int64 {
+	if x != nil {
+		return x.PrincipalMinor
+	}
+	return 0
+}
+
+func (x *Payment) GetFeeMinor() int64 {
+	if x != nil {
+		return x.FeeMinor
+	}
+	return 0
+}
+
+func (x *Payment) GetBalanceMinor() int64 {
+	if x != nil {
+		return x.BalanceMinor
+	}
+	return 0
+}
+
+// Lender is a lender in the market.
+type Lender struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Name      string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Rate      float64                `protobuf:"fixed64,2,opt,name=rate,proto3" json:"rate,omitempty"`
+	Available int64                  `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	Currency  string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	// The shortest and longest loans in months the lender will fund. Zero means
+	// there is no limit.
+	MinTerm       int32  `protobuf:"varint,5,opt,name=min_term,json=minTerm,proto3" json:"min_term,omitempty"`
+	MaxTerm       int32  `protobuf:"varint,6,opt,name=max_term,json=maxTerm,proto3" json:"max_term,omitempty"`
+	RiskBand      string `protobuf:"bytes,7,opt,name=risk_band,json=riskBand,proto3" json:"risk_band,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Lender) Reset() {
+	*x = Lender{}
+	mi := &file_quote_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Lender) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Lender) ProtoMessage() {}
+
+func (x *Lender) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Lender.ProtoReflect.Descriptor instead.
+func (*Lender) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Lender) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Lender) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Lender) GetAvailable() int64 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *Lender) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Lender) GetMinTerm() int32 {
+	if x != nil {
+		return x.MinTerm
+	}
+	return 0
+}
+
+func (x *Lender) GetMaxTerm() int32 {
+	if x != nil {
+		return x.MaxTerm
+	}
+	return 0
+}
+
+func (x *Lender) GetRiskBand() string {
+	if x != nil {
+		return x.RiskBand
+	}
+	return ""
+}
+
+// ListLendersRequest has no fields.
+type ListLendersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLendersRequest) Reset() {
+	*x = ListLendersRequest{}
+	mi := &file_quote_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLendersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLendersRequest) ProtoMessage() {}
+
+func (x *ListLendersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLendersRequest.ProtoReflect.Descriptor instead.
+func (*ListLendersRequest) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{6}
+}
+
+// ListLendersResponse is the market being quoted from.
+type ListLendersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Currency      string                 `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	Available     int64                  `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
+	Lenders       []*Lender              `protobuf:"bytes,3,rep,name=lenders,proto3" json:"lenders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLendersResponse) Reset() {
+	*x = ListLendersResponse{}
+	mi := &file_quote_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLendersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLendersResponse) ProtoMessage() {}
+
+func (x *ListLendersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLendersResponse.ProtoReflect.Descriptor instead.
+func (*ListLendersResponse) Descriptor() ([]byte, []int) {
+	return file_quote_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListLendersResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *ListLendersResponse) GetAvailable() int64 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *ListLendersResponse) GetLenders() []*Lender {
+	if x != nil {
+		return x.Lenders
+	}
+	return nil
+}
+
+var File_quote_proto protoreflect.FileDescriptor
+
+const file_quote_proto_rawDesc = "" +
+	"\n" +
+	"\vquote.proto\x12\n" +
+	"goquote.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9f\x02\n" +
+	"\x12CreateQuoteRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x03R\x06amount\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x05R\x04term\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12\x1a\n" +
+	"\brounding\x18\x04 \x01(\tR\brounding\x12\x1a\n" +
+	"\bstrategy\x18\x05 \x01(\tR\bstrategy\x12#\n" +
+	"\rallow_partial\x18\x06 \x01(\bR\fallowPartial\x129\n" +
+	"\n" +
+	"start_date\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x12)\n" +
+	"\x10include_schedule\x18\b \x01(\bR\x0fincludeSchedule\"\xc0\x06\n" +
+	"\x05Quote\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12%\n" +
+	"\x0epolicy_version\x18\x04 \x01(\tR\rpolicyVersion\x12\x1f\n" +
+	"\vmarket_hash\x18\x05 \x01(\tR\n" +
+	"marketHash\x12)\n" +
+	"\x10requested_amount\x18\x06 \x01(\x03R\x0frequestedAmount\x12#\n" +
+	"\rfunded_amount\x18\a \x01(\x03R\ffundedAmount\x12\x1c\n" +
+	"\tshortfall\x18\b \x01(\x03R\tshortfall\x12\x1a\n" +
+	"\bcurrency\x18\t \x01(\tR\bcurrency\x12\x12\n" +
+	"\x04term\x18\n" +
+	" \x01(\x05R\x04term\x129\n" +
+	"\n" +
+	"start_date\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x12\x12\n" +
+	"\x04rate\x18\f \x01(\x01R\x04rate\x12\x10\n" +
+	"\x03apr\x18\r \x01(\x01R\x03apr\x126\n" +
+	"\x17monthly_repayment_minor\x18\x0e \x01(\x03R\x15monthlyRepaymentMinor\x122\n" +
+	"\x15total_repayment_minor\x18\x0f \x01(\x03R\x13totalRepaymentMinor\x122\n" +
+	"\x15amount_received_minor\x18\x10 \x01(\x03R\x13amountReceivedMinor\x12:\n" +
+	"\x1atotal_cost_of_credit_minor\x18\x11 \x01(\x03R\x16totalCostOfCreditMinor\x12#\n" +
+	"\x04fees\x18\x12 \x03(\v2\x0f.goquote.v1.FeeR\x04fees\x128\n" +
+	"\vallocations\x18\x13 \x03(\v2\x16.goquote.v1.AllocationR\vallocations\x12/\n" +
+	"\bschedule\x18\x14 \x03(\v2\x13.goquote.v1.PaymentR\bschedule\"u\n" +
+	"\x03Fee\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06timing\x18\x02 \x01(\tR\x06timing\x12!\n" +
+	"\famount_minor\x18\x03 \x01(\x03R\vamountMinor\x12\x1f\n" +
+	"\vtotal_minor\x18\x04 \x01(\x03R\n" +
+	"totalMinor\"\xba\x01\n" +
+	"\n" +
+	"Allocation\x12\x16\n" +
+	"\x06lender\x18\x01 \x01(\tR\x06lender\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x03R\x06amount\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x01R\x04rate\x126\n" +
+	"\x17monthly_repayment_minor\x18\x04 \x01(\x03R\x15monthlyRepaymentMinor\x120\n" +
+	"\x14total_interest_minor\x18\x05 \x01(\x03R\x12totalInterestMinor\"\x86\x02\n" +
+	"\aPayment\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12.\n" +
+	"\x04date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12!\n" +
+	"\famount_minor\x18\x03 \x01(\x03R\vamountMinor\x12%\n" +
+	"\x0einterest_minor\x18\x04 \x01(\x03R\rinterestMinor\x12'\n" +
+	"\x0fprincipal_minor\x18\x05 \x01(\x03R\x0eprincipalMinor\x12\x1b\n" +
+	"\tfee_minor\x18\x06 \x01(\x03R\bfeeMinor\x12#\n" +
+	"\rbalance_minor\x18\a \x01(\x03R\fbalanceMinor\"\xbd\x01\n" +
+	"\x06Lender\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04rate\x18\x02 \x01(\x01R\x04rate\x12\x1c\n" +
+	"\tavailable\x18\x03 \x01(\x03R\tavailable\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12\x19\n" +
+	"\bmin_term\x18\x05 \x01(\x05R\aminTerm\x12\x19\n" +
+	"\bmax_term\x18\x06 \x01(\x05R\amaxTerm\x12\x1b\n" +
+	"\trisk_band\x18\a \x01(\tR\briskBand\"\x14\n" +
+	"\x12ListLendersRequest\"}\n" +
+	"\x13ListLendersResponse\x12\x1a\n" +
+	"\bcurrency\x18\x01 \x01(\tR\bcurrency\x12\x1c\n" +
+	"\tavailable\x18\x02 \x01(\x03R\tavailable\x12,\n" +
+	"\alenders\x18\x03 \x03(\v2\x12.goquote.v1.LenderR\alenders2\xa0\x01\n" +
+	"\fQuoteService\x12@\n" +
+	"\vCreateQuote\x12\x1e.goquote.v1.CreateQuoteRequest\x1a\x11.goquote.v1.Quote\x12N\n" +
+	"\vListLenders\x12\x1e.goquote.v1.ListLendersRequest\x1a\x1f.goquote.v1.ListLendersResponseB(Z&github.com/eazynow/goquote/rpc/quotepbb\x06proto3"
+
+var (
+	file_quote_proto_rawDescOnce sync.Once
+	file_quote_proto_rawDescData []byte
+)
+
+func file_quote_proto_rawDescGZIP() []byte {
+	file_quote_proto_rawDescOnce.Do(func() {
+		file_quote_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_quote_proto_rawDesc), len(file_quote_proto_rawDesc)))
+	})
+	return file_quote_proto_rawDescData
+}
+
+var file_quote_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_quote_proto_goTypes = []any{
+	(*CreateQuoteRequest)(nil),    // 0: goquote.v1.CreateQuoteRequest
+	(*Quote)(nil),                 // 1: goquote.v1.Quote
+	(*Fee)(nil),                   // 2: goquote.v1.Fee
+	(*Allocation)(nil),            // 3: goquote.v1.Allocation
+	(*Payment)(nil),               // 4: goquote.v1.Payment
+	(*Lender)(nil),                // 5: goquote.v1.Lender
+	(*ListLendersRequest)(nil),    // 6: goquote.v1.ListLendersRequest
+	(*ListLendersResponse)(nil),   // 7: goquote.v1.ListLendersResponse
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_quote_proto_depIdxs = []int32{
+	8,  // 0: goquote.v1.CreateQuoteRequest.start_date:type_name -> google.protobuf.Timestamp
+	8,  // 1: goquote.v1.Quote.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 2: goquote.v1.Quote.expires_at:type_name -> google.protobuf.Timestamp
+	8,  // 3: goquote.v1.Quote.start_date:type_name -> google.protobuf.Timestamp
+	2,  // 4: goquote.v1.Quote.fees:type_name -> goquote.v1.Fee
+	3,  // 5: goquote.v1.Quote.allocations:type_name -> goquote.v1.Allocation
+	4,  // 6: goquote.v1.Quote.schedule:type_name -> goquote.v1.Payment
+	8,  // 7: goquote.v1.Payment.date:type_name -> google.protobuf.Timestamp
+	5,  // 8: goquote.v1.ListLendersResponse.lenders:type_name -> goquote.v1.Lender
+	0,  // 9: goquote.v1.QuoteService.CreateQuote:input_type -> goquote.v1.CreateQuoteRequest
+	6,  // 10: goquote.v1.QuoteService.ListLenders:input_type -> goquote.v1.ListLendersRequest
+	1,  // 11: goquote.v1.QuoteService.CreateQuote:output_type -> goquote.v1.Quote
+	7,  // 12: goquote.v1.QuoteService.ListLenders:output_type -> goquote.v1.ListLendersResponse
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_quote_proto_init() }
+func file_quote_proto_init() {
+	if File_quote_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_quote_proto_rawDesc), len(file_quote_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_quote_proto_goTypes,
+		DependencyIndexes: file_quote_proto_depIdxs,
+		MessageInfos:      file_quote_proto_msgTypes,
+	}.Build()
+	File_quote_proto = out.File
+	file_quote_proto_goTypes = nil
+	file_quote_proto_depIdxs = nil
+}
@@ -0,0 +1,175 @@
+// Package rpc exposes the quoting engine as a gRPC service. The service is
+// defined in quotepb/quote.proto.
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/money"
+	"github.com/eazynow/goquote/quote"
+	"github.com/eazynow/goquote/rpc/quotepb"
+)
+
+// Server implements the quotepb.QuoteServiceServer interface. Register it
+// with a grpc.Server using quotepb.RegisterQuoteServiceServer.
+type Server struct {
+	quotepb.UnimplementedQuoteServiceServer
+
+	market func() lender.Lenders
+	policy quote.Policy
+}
+
+// New is a function used to create a gRPC server quoting under the policy
+// from the lenders returned by market, which is called for every request so
+// that the market can change while the server is running. It is usually the
+// Market function of the HTTP server, so both APIs quote from the same
+// market.
+// Returns a pointer to the new Server.
+func New(market func() lender.Lenders, policy quote.Policy) *Server {
+	return &Server{market: market, policy: policy}
+}
+
+// CreateQuote calculates a quote for a loan.
+func (s *Server) CreateQuote(ctx context.Context, in *quotepb.CreateQuoteRequest) (*quotepb.Quote, error) {
+	params := quote.RequestParams{
+		Amount:       int(in.GetAmount()),
+		Term:         int(in.GetTerm()),
+		Currency:     in.GetCurrency(),
+		Rounding:     in.GetRounding(),
+		Strategy:     in.GetStrategy(),
+		AllowPartial: in.GetAllowPartial(),
+	}
+
+	req, err := params.Build(s.market(), s.policy)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if in.GetStartDate() != nil {
+		req.Options.StartDate = in.GetStartDate().AsTime()
+	}
+
+	q, err := quote.Calculate(req)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return quoteToProto(q, in.GetIncludeSchedule()), nil
+}
+
+// ListLenders lists the lenders in the market.
+func (s *Server) ListLenders(ctx context.Context, in *quotepb.ListLendersRequest) (*quotepb.ListLendersResponse, error) {
+	lenders := s.market()
+
+	currency, err := lenders.Currency()
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &quotepb.ListLendersResponse{Currency: currency}
+	for _, l := range lenders {
+		resp.Available += int64(l.Available)
+		resp.Lenders = append(resp.Lenders, &quotepb.Lender{
+			Name:      l.Name,
+			Rate:      l.Rate,
+			Available: int64(l.Available),
+			Currency:  l.Currency,
+			MinTerm:   int32(l.MinTerm),
+			MaxTerm:   int32(l.MaxTerm),
+			RiskBand:  l.RiskBand,
+		})
+	}
+
+	return resp, nil
+}
+
+// statusError maps an error to a gRPC status error with the code that
+// matches the kind of error.
+// Returns the status error.
+func statusError(err error) error {
+	var reqErr *quote.RequestError
+
+	code := codes.Internal
+	switch {
+	case errors.As(err, &reqErr):
+		code = codes.InvalidArgument
+	case errors.Is(err, lender.ErrMixedCurrencies):
+		code = codes.Unavailable
+	case errors.Is(err, quote.ErrInsufficientFunds):
+		code = codes.ResourceExhausted
+	case quote.IsRejection(err):
+		code = codes.FailedPrecondition
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// quoteToProto converts a quote into its protobuf message, including the
+// repayment schedule if requested.
+// Returns the Quote message.
+func quoteToProto(q *quote.Quote, withSchedule bool) *quotepb.Quote {
+	out := &quotepb.Quote{
+		Id:                     q.ID,
+		CreatedAt:              timestamppb.New(q.CreatedAt),
+		ExpiresAt:              timestamppb.New(q.ExpiresAt),
+		PolicyVersion:          q.PolicyVersion,
+		MarketHash:             q.MarketHash,
+		RequestedAmount:        int64(q.RequestedAmount),
+		FundedAmount:           int64(q.FundedAmount),
+		Shortfall:              int64(q.Shortfall),
+		Currency:               q.Currency,
+		Term:                   int32(q.Term),
+		StartDate:              timestamppb.New(q.StartDate),
+		Rate:                   q.Rate,
+		Apr:                    q.APR,
+		MonthlyRepaymentMinor:  minor(q.MonthlyRepayment),
+		TotalRepaymentMinor:    minor(q.TotalRepayment),
+		AmountReceivedMinor:    minor(q.AmountReceived),
+		TotalCostOfCreditMinor: minor(q.TotalCostOfCredit),
+	}
+
+	for _, f := range q.Fees {
+		out.Fees = append(out.Fees, &quotepb.Fee{
+			Type:        string(f.Type),
+			Timing:      string(f.Timing),
+			AmountMinor: minor(f.Amount),
+			TotalMinor:  minor(f.Total),
+		})
+	}
+
+	for _, a := range q.Allocations {
+		out.Allocations = append(out.Allocations, &quotepb.Allocation{
+			Lender:                a.Lender,
+			Amount:                int64(a.Amount),
+			Rate:                  a.Rate,
+			MonthlyRepaymentMinor: minor(a.MonthlyRepayment),
+			TotalInterestMinor:    minor(a.TotalInterest),
+		})
+	}
+
+	if withSchedule {
+		for _, p := range q.Schedule(q.StartDate) {
+			out.Schedule = append(out.Schedule, &quotepb.Payment{
+				Number:         int32(p.Number),
+				Date:           timestamppb.New(p.Date),
+				AmountMinor:    minor(p.Amount),
+				InterestMinor:  minor(p.Interest),
+				PrincipalMinor: minor(p.Principal),
+				FeeMinor:       minor(p.Fee),
+				BalanceMinor:   minor(p.Balance),
+			})
+		}
+	}
+
+	return out
+}
+
+// minor converts an amount into minor units for a protobuf message.
+func minor(a money.Amount) int64 {
+	return int64(a)
+}
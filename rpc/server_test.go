@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/quote"
+	"github.com/eazynow/goquote/rpc/quotepb"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMarket is a small market used by the tests.
+func testMarket() lender.Lenders {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+	return lenders
+}
+
+// newTestClient starts a gRPC server quoting from the market on an in-memory
+// listener, so the tests run without opening a network port.
+// Returns a client connected to the server.
+func newTestClient(t *testing.T, market lender.Lenders) quotepb.QuoteServiceClient {
+	listener := bufconn.Listen(1 << 20)
+
+	s := grpc.NewServer()
+	quotepb.RegisterQuoteServiceServer(s, New(func() lender.Lenders { return market }, quote.DefaultPolicy()))
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Unable to connect to the test server: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return quotepb.NewQuoteServiceClient(conn)
+}
+
+func TestCreateQuote(t *testing.T) {
+	assert := assert.New(t)
+	client := newTestClient(t, testMarket())
+
+	start := time.Date(2015, time.January, 15, 0, 0, 0, 0, time.UTC)
+	q, err := client.CreateQuote(context.Background(), &quotepb.CreateQuoteRequest{
+		Amount:          1200,
+		StartDate:       timestamppb.New(start),
+		IncludeSchedule: true,
+	})
+
+	assert.Nil(err)
+	assert.Equal(int64(1200), q.GetRequestedAmount())
+	assert.Equal(int32(quote.DefaultTerm), q.GetTerm(), "Expected the default term")
+	assert.Equal(int64(3618), q.GetMonthlyRepaymentMinor())
+	assert.Equal("GBP", q.GetCurrency())
+	assert.Equal(2, len(q.GetAllocations()))
+	assert.Equal(36, len(q.GetSchedule()))
+	assert.True(start.Equal(q.GetStartDate().AsTime()))
+	assert.NotEmpty(q.GetId())
+	assert.Equal(quote.DefaultPolicyVersion, q.GetPolicyVersion())
+}
+
+func TestCreateQuoteWithoutSchedule(t *testing.T) {
+	q, err := newTestClient(t, testMarket()).CreateQuote(context.Background(), &quotepb.CreateQuoteRequest{Amount: 1000})
+
+	assert.Nil(t, err)
+	assert.Empty(t, q.GetSchedule(), "Expected no schedule unless requested")
+}
+
+func TestCreateQuotePartial(t *testing.T) {
+	assert := assert.New(t)
+
+	q, err := newTestClient(t, testMarket()).CreateQuote(context.Background(), &quotepb.CreateQuoteRequest{
+		Amount:       1300,
+		AllowPartial: true,
+	})
+
+	assert.Nil(err)
+	assert.Equal(int64(1200), q.GetFundedAmount())
+	assert.Equal(int64(100), q.GetShortfall())
+}
+
+func TestCreateQuoteErrors(t *testing.T) {
+	client := newTestClient(t, testMarket())
+
+	tests := []struct {
+		name string
+		req  *quotepb.CreateQuoteRequest
+		code codes.Code
+	}{
+		{"bad strategy", &quotepb.CreateQuoteRequest{Amount: 1000, Strategy: "random"}, codes.InvalidArgument},
+		{"bad rounding", &quotepb.CreateQuoteRequest{Amount: 1000, Rounding: "down"}, codes.InvalidArgument},
+		{"too low", &quotepb.CreateQuoteRequest{Amount: 900}, codes.FailedPrecondition},
+		{"bad step", &quotepb.CreateQuoteRequest{Amount: 1050}, codes.FailedPrecondition},
+		{"insufficient funds", &quotepb.CreateQuoteRequest{Amount: 1300}, codes.ResourceExhausted},
+	}
+
+	for _, test := range tests {
+		_, err := client.CreateQuote(context.Background(), test.req)
+		assert.Equal(t, test.code, status.Code(err), test.name)
+	}
+}
+
+func TestListLenders(t *testing.T) {
+	assert := assert.New(t)
+
+	resp, err := newTestClient(t, testMarket()).ListLenders(context.Background(), &quotepb.ListLendersRequest{})
+
+	assert.Nil(err)
+	assert.Equal("GBP", resp.GetCurrency())
+	assert.Equal(int64(1200), resp.GetAvailable())
+	assert.Equal(2, len(resp.GetLenders()))
+	assert.Equal("Jane", resp.GetLenders()[0].GetName())
+}
+
+func TestListLendersMixedCurrencies(t *testing.T) {
+	market := testMarket()
+	market[0].Currency = "GBP"
+	market[1].Currency = "EUR"
+
+	_, err := newTestClient(t, market).ListLenders(context.Background(), &quotepb.ListLendersRequest{})
+
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,224 @@
+// Package server exposes the quoting engine as a JSON API over HTTP.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/quote"
+)
+
+// maxRequestSize is the largest request body the server will read.
+const maxRequestSize = 1 << 20
+
+// The error codes returned in an ErrorResponse, so that clients can tell the
+// errors apart without parsing the message.
+const (
+	// CodeInvalidRequest is used when the request cannot be understood.
+	CodeInvalidRequest = "invalid_request"
+	// CodeRejected is used when the request breaks the quote policy.
+	CodeRejected = "rejected"
+	// CodeInsufficientFunds is used when the market cannot fund the loan.
+	CodeInsufficientFunds = "insufficient_funds"
+	// CodeMarketUnavailable is used when the market cannot be quoted from.
+	CodeMarketUnavailable = "market_unavailable"
+	// CodeInternal is used for any other error.
+	CodeInternal = "internal"
+)
+
+// QuoteRequest represents the body of a POST /quotes request. Only the amount
+// is required.
+type QuoteRequest struct {
+	quote.RequestParams
+	// Schedule includes the repayment schedule in the response.
+	Schedule bool `json:"schedule"`
+}
+
+// MarketResponse represents the body of a GET /market response.
+type MarketResponse struct {
+	// Currency is the code of the currency the market lends in.
+	Currency string `json:"currency"`
+	// Available is the total funds available across every lender.
+	Available int `json:"available"`
+	// Lenders is every lender in the market.
+	Lenders lender.Lenders `json:"lenders"`
+}
+
+// ErrorResponse represents the body of a response for a request that failed.
+type ErrorResponse struct {
+	// Code is one of the error codes, such as CodeRejected.
+	Code string `json:"code"`
+	// Error is a message describing the error.
+	Error string `json:"error"`
+}
+
+// Server is an http.Handler serving the quoting API:
+//
+//	POST /quotes   calculate a quote
+//	GET  /market   list the lenders in the market
+//	GET  /healthz  check the server is running
+//	GET  /readyz   check the server has a market to quote from
+//
+// The market can be replaced while the server is running, and quotes already
+// being calculated keep the market they started with.
+type Server struct {
+	policy quote.Policy
+	market atomic.Value
+	mux    *http.ServeMux
+}
+
+// New is a function used to create a server quoting from the lenders, such as
+// those loaded with lender.ImportCSV, under the policy.
+// Returns a pointer to the new Server.
+func New(lenders lender.Lenders, policy quote.Policy) *Server {
+	s := &Server{
+		policy: policy,
+		mux:    http.NewServeMux(),
+	}
+	s.SetMarket(lenders)
+
+	s.mux.HandleFunc("/quotes", s.handleQuotes)
+	s.mux.HandleFunc("/market", s.handleMarket)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+
+	return s
+}
+
+// Market is a function used to get the lenders currently being quoted from.
+// The slice must not be changed.
+// Returns the current lenders.
+func (s *Server) Market() lender.Lenders {
+	return s.market.Load().(lender.Lenders)
+}
+
+// SetMarket is a function used to replace the lenders being quoted from. A
+// copy of the lenders is taken, so the caller can reuse the slice.
+func (s *Server) SetMarket(lenders lender.Lenders) {
+	market := make(lender.Lenders, len(lenders))
+	copy(market, lenders)
+	s.market.Store(market)
+}
+
+// ServeHTTP is used to satisfy the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleQuotes calculates a quote for a POST /quotes request.
+func (s *Server) handleQuotes(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var body QuoteRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, quote.NewRequestError(fmt.Sprintf("The request body is not valid: %s", err)))
+		return
+	}
+
+	req, err := body.Build(s.Market(), s.policy)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	q, err := quote.Calculate(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	q.Encode(w, quote.FormatJSON, body.Schedule)
+}
+
+// handleMarket lists the lenders for a GET /market request.
+func (s *Server) handleMarket(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	lenders := s.Market()
+	currency, err := lenders.Currency()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := MarketResponse{Currency: currency, Lenders: lenders}
+	for _, l := range lenders {
+		resp.Available += l.Available
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleHealth reports that the server is running.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports whether the server has any lenders to quote from.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if len(s.Market()) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "no lenders in the market"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// allowMethod checks that the request uses the method, and writes a 405
+// response if not.
+// Returns true if the request can be handled.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method)
+	writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{
+		Code:  CodeInvalidRequest,
+		Error: fmt.Sprintf("The method %s is not allowed", r.Method),
+	})
+	return false
+}
+
+// writeError writes an ErrorResponse for err, with the status and code that
+// match the kind of error.
+func writeError(w http.ResponseWriter, err error) {
+	status, code := classify(err)
+	writeJSON(w, status, ErrorResponse{Code: code, Error: err.Error()})
+}
+
+// classify maps an error to the HTTP status and error code returned for it.
+// Returns the status and code.
+func classify(err error) (int, string) {
+	var reqErr *quote.RequestError
+
+	switch {
+	case errors.As(err, &reqErr):
+		return http.StatusBadRequest, CodeInvalidRequest
+	case errors.Is(err, lender.ErrMixedCurrencies):
+		return http.StatusServiceUnavailable, CodeMarketUnavailable
+	case errors.Is(err, quote.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity, CodeInsufficientFunds
+	case quote.IsRejection(err):
+		return http.StatusUnprocessableEntity, CodeRejected
+	}
+
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// writeJSON writes v as the JSON body of a response with the status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eazynow/goquote/lender"
+	"github.com/eazynow/goquote/quote"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer creates a server with a small market and the default policy.
+func newTestServer() *Server {
+	var lenders lender.Lenders
+	lenders = append(lenders, lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000})
+	lenders = append(lenders, lender.Lender{Name: "Bob", Rate: 0.069, Available: 200})
+
+	return New(lenders, quote.DefaultPolicy())
+}
+
+// do sends a request to the server.
+// Returns the recorded response.
+func do(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestPostQuotes(t *testing.T) {
+	assert := assert.New(t)
+
+	w := do(newTestServer(), http.MethodPost, "/quotes", `{"amount": 1200, "start_date": "2015-01-15", "schedule": true}`)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("application/json", w.Header().Get("Content-Type"))
+
+	var resp struct {
+		Quote    quote.Quote     `json:"quote"`
+		Schedule []quote.Payment `json:"schedule"`
+	}
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &resp), "Expected a JSON quote")
+	assert.Equal(1200, resp.Quote.RequestedAmount)
+	assert.Equal(quote.DefaultTerm, resp.Quote.Term, "Expected the default term")
+	assert.Equal("36.18", resp.Quote.MonthlyRepayment.String())
+	assert.Equal(2, len(resp.Quote.Allocations))
+	assert.Equal(36, len(resp.Schedule))
+	assert.NotEmpty(resp.Quote.ID)
+}
+
+func TestPostQuotesWithOptions(t *testing.T) {
+	w := do(newTestServer(), http.MethodPost, "/quotes",
+		`{"amount": 1300, "term": 24, "strategy": "pro-rata", "rounding": "half-even", "allow_partial": true}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Quote quote.Quote `json:"quote"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp), "Expected a JSON quote")
+	assert.Equal(t, 24, resp.Quote.Term)
+	assert.Equal(t, 1200, resp.Quote.FundedAmount)
+	assert.Equal(t, 100, resp.Quote.Shortfall)
+}
+
+func TestPostQuotesErrors(t *testing.T) {
+	for body, expected := range map[string]struct {
+		status int
+		code   string
+	}{
+		`{"amount": `:                         {http.StatusBadRequest, CodeInvalidRequest},
+		`{"amount": 1200, "colour": "red"}`:   {http.StatusBadRequest, CodeInvalidRequest},
+		`{"amount": 1200, "strategy": "x"}`:   {http.StatusBadRequest, CodeInvalidRequest},
+		`{"amount": 1200, "currency": "x"}`:   {http.StatusBadRequest, CodeInvalidRequest},
+		`{"amount": 1200, "start_date": "x"}`: {http.StatusBadRequest, CodeInvalidRequest},
+		`{"amount": 900}`:                     {http.StatusUnprocessableEntity, CodeRejected},
+		`{"amount": 1200, "term": 7}`:         {http.StatusUnprocessableEntity, CodeRejected},
+		`{"amount": 1300}`:                    {http.StatusUnprocessableEntity, CodeInsufficientFunds},
+	} {
+		w := do(newTestServer(), http.MethodPost, "/quotes", body)
+
+		var resp ErrorResponse
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp), "Expected a JSON error for %s", body)
+		assert.Equal(t, expected.status, w.Code, "Unexpected status for %s", body)
+		assert.Equal(t, expected.code, resp.Code, "Unexpected code for %s", body)
+		assert.NotEmpty(t, resp.Error)
+	}
+}
+
+func TestQuotesOnlyAllowsPost(t *testing.T) {
+	w := do(newTestServer(), http.MethodGet, "/quotes", "")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+}
+
+func TestGetMarket(t *testing.T) {
+	assert := assert.New(t)
+
+	w := do(newTestServer(), http.MethodGet, "/market", "")
+
+	assert.Equal(http.StatusOK, w.Code)
+
+	var resp MarketResponse
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &resp), "Expected a JSON market")
+	assert.Equal("GBP", resp.Currency)
+	assert.Equal(1200, resp.Available)
+	assert.Equal(lender.Lender{Name: "Jane", Rate: 0.051, Available: 1000}, resp.Lenders[0])
+}
+
+func TestSetMarket(t *testing.T) {
+	s := newTestServer()
+
+	lenders := lender.Lenders{{Name: "Sam", Rate: 0.05, Available: 5000}}
+	s.SetMarket(lenders)
+	lenders[0].Available = 0
+
+	assert.Equal(t, 5000, s.Market()[0].Available, "Expected the server to keep its own copy")
+
+	w := do(s, http.MethodPost, "/quotes", `{"amount": 5000}`)
+	assert.Equal(t, http.StatusOK, w.Code, "Expected the new market to be quoted from")
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	s := newTestServer()
+
+	assert.Equal(t, http.StatusOK, do(s, http.MethodGet, "/healthz", "").Code)
+	assert.Equal(t, http.StatusOK, do(s, http.MethodGet, "/readyz", "").Code)
+
+	s.SetMarket(nil)
+
+	assert.Equal(t, http.StatusOK, do(s, http.MethodGet, "/healthz", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, do(s, http.MethodGet, "/readyz", "").Code,
+		"Expected the server not to be ready without lenders")
+}